@@ -0,0 +1,351 @@
+// mongodb_exporter
+// Copyright (C) 2017 Percona LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Results of the migrations counter.
+const (
+	migrationResultCommit = "commit"
+	migrationResultAbort  = "abort"
+)
+
+// balancerStatus is the reply of the balancerStatus command.
+type balancerStatus struct {
+	Mode              string `bson:"mode"`
+	InBalancerRound   bool   `bson:"inBalancerRound"`
+	NumBalancerRounds int64  `bson:"numBalancerRounds"`
+}
+
+// balancerWindow is the time of the day the balancer can run, as HH:MM.
+type balancerWindow struct {
+	Start string `bson:"start"`
+	Stop  string `bson:"stop"`
+}
+
+// balancerSettings is the balancer document of config.settings.
+type balancerSettings struct {
+	Stopped      bool            `bson:"stopped"`
+	ActiveWindow *balancerWindow `bson:"activeWindow"`
+}
+
+// changelogEvent is a migration event of config.changelog.
+type changelogEvent struct {
+	ID      string    `bson:"_id"`
+	What    string    `bson:"what"`
+	Time    time.Time `bson:"time"`
+	Details bson.M    `bson:"details"`
+}
+
+// balancerTracker reads the migration events of config.changelog from a high-water
+// mark and keeps the counters and histograms built from them between scrapes.
+type balancerTracker struct {
+	mu      sync.Mutex
+	started bool
+	// Time of the newest event read and the IDs of the events read at that time.
+	hwm  time.Time
+	seen map[string]bool
+
+	migrations *prometheus.CounterVec
+	steps      *prometheus.HistogramVec
+}
+
+func newBalancerTracker() *balancerTracker {
+	return &balancerTracker{
+		seen: make(map[string]bool),
+		migrations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "mongodb_balancer_migrations_total",
+			Help: "Number of committed and aborted chunk migrations read from config.changelog since the exporter started",
+		}, []string{"operation", "result"}),
+		steps: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name: "mongodb_balancer_migration_step_duration_seconds",
+			Help: "Duration of the steps of the chunk migrations read from config.changelog, on the donor (from) and recipient (to) shards",
+			// 10ms to ~160s.
+			Buckets: prometheus.ExponentialBuckets(0.01, 2, 15), //nolint:mnd
+		}, []string{"operation", "side", "step"}),
+	}
+}
+
+// read reads the migration events logged since the last read. The first read only
+// records the time of the newest event, the older events are not counted.
+func (t *balancerTracker) read(ctx context.Context, client *mongo.Client) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	changelog := client.Database("config").Collection("changelog")
+	filter := bson.M{"what": bson.M{"$regex": "^(moveChunk|moveRange)\\."}}
+
+	if !t.started {
+		var newest changelogEvent
+		opts := options.FindOne().SetSort(bson.M{"time": -1}).SetProjection(bson.M{"time": 1})
+		err := changelog.FindOne(ctx, filter, opts).Decode(&newest)
+		if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+			return fmt.Errorf("cannot read config.changelog: %w", err)
+		}
+
+		t.hwm = newest.Time
+		t.started = true
+
+		return nil
+	}
+
+	filter["time"] = bson.M{"$gte": t.hwm}
+	cursor, err := changelog.Find(ctx, filter, options.Find().SetSort(bson.M{"time": 1}))
+	if err != nil {
+		return fmt.Errorf("cannot read config.changelog: %w", err)
+	}
+
+	var events []changelogEvent
+	if err := cursor.All(ctx, &events); err != nil {
+		return fmt.Errorf("cannot decode config.changelog events: %w", err)
+	}
+
+	t.observe(events)
+
+	return nil
+}
+
+// observe counts the events not read yet and moves the high-water mark. Events must
+// be sorted by time. It must be called with mu held.
+func (t *balancerTracker) observe(events []changelogEvent) {
+	for _, e := range events {
+		if e.Time.Before(t.hwm) || t.seen[e.ID] {
+			continue
+		}
+
+		if e.Time.After(t.hwm) {
+			t.hwm = e.Time
+			t.seen = make(map[string]bool)
+		}
+		t.seen[e.ID] = true
+
+		operation, event, ok := strings.Cut(e.What, ".")
+		if !ok {
+			continue
+		}
+
+		switch event {
+		case "commit":
+			t.migrations.WithLabelValues(operation, migrationResultCommit).Inc()
+		case "error":
+			t.migrations.WithLabelValues(operation, migrationResultAbort).Inc()
+		case "from", "to":
+			for key, value := range e.Details {
+				// Steps are logged as "step <n> of <total>" with a duration in milliseconds.
+				step, ok := strings.CutPrefix(key, "step ")
+				if !ok {
+					continue
+				}
+				step, _, _ = strings.Cut(step, " ")
+
+				ms, err := asFloat64(value)
+				if err != nil || ms == nil {
+					continue
+				}
+
+				t.steps.WithLabelValues(operation, event, step).Observe((time.Duration(*ms) * time.Millisecond).Seconds())
+			}
+		}
+	}
+}
+
+// collect sends the counters and histograms built from the changelog.
+func (t *balancerTracker) collect(ch chan<- prometheus.Metric) {
+	t.migrations.Collect(ch)
+	t.steps.Collect(ch)
+}
+
+type balancerCollector struct {
+	ctx  context.Context
+	base *baseCollector
+
+	topologyInfo labelsGetter
+	tracker      *balancerTracker
+}
+
+// newBalancerCollector creates a collector for the state of the balancer and the
+// chunk migrations of a sharded cluster.
+func newBalancerCollector(ctx context.Context, client *mongo.Client, logger *slog.Logger, topology labelsGetter, tracker *balancerTracker) *balancerCollector {
+	return &balancerCollector{
+		ctx:  ctx,
+		base: newBaseCollector(client, logger.With("collector", "balancer")),
+
+		topologyInfo: topology,
+		tracker:      tracker,
+	}
+}
+
+func (d *balancerCollector) Describe(ch chan<- *prometheus.Desc) {
+	d.base.Describe(d.ctx, ch, d.collect)
+}
+
+func (d *balancerCollector) Collect(ch chan<- prometheus.Metric) {
+	d.base.Collect(ch)
+}
+
+func (d *balancerCollector) collect(ch chan<- prometheus.Metric) {
+	defer measureCollectTime(ch, "mongodb", "balancer")()
+
+	logger := d.base.logger
+	client := d.base.client
+	if client == nil {
+		return
+	}
+
+	labels := d.topologyInfo.baseLabels()
+	var metrics []prometheus.Metric
+
+	var status balancerStatus
+	if err := client.Database("admin").RunCommand(d.ctx, bson.D{{Key: "balancerStatus", Value: 1}}).Decode(&status); err != nil {
+		logger.Error("cannot get balancerStatus", "error", err)
+	} else {
+		metrics = append(metrics, balancerStatusMetrics(&status, labels)...)
+	}
+
+	var settings balancerSettings
+	err := client.Database("config").Collection("settings").FindOne(d.ctx, bson.M{"_id": "balancer"}).Decode(&settings)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		logger.Error("cannot get the balancer settings", "error", err)
+	} else {
+		metrics = append(metrics, balancerSettingsMetrics(&settings, labels)...)
+	}
+
+	if n, err := migrationsInProgress(d.ctx, client); err != nil {
+		logger.Error("cannot count the migrations in progress", "error", err)
+	} else {
+		desc := prometheus.NewDesc("mongodb_balancer_migrations_in_progress",
+			"Number of chunk migrations in progress on the donor shards", nil, labels)
+		metrics = append(metrics, prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, float64(n)))
+	}
+
+	if err := d.tracker.read(d.ctx, client); err != nil {
+		logger.Error("cannot read the migration events", "error", err)
+	}
+
+	for _, metric := range metrics {
+		ch <- metric
+	}
+
+	d.tracker.collect(ch)
+}
+
+// migrationsInProgress counts the running migrations commands on the donor shards.
+func migrationsInProgress(ctx context.Context, client *mongo.Client) (int, error) {
+	pipeline := bson.A{
+		bson.M{"$currentOp": bson.M{"allUsers": true, "localOps": false}},
+		bson.M{"$match": bson.M{"$or": bson.A{
+			bson.M{"command.moveChunk": bson.M{"$exists": true}},
+			bson.M{"command._shardsvrMoveRange": bson.M{"$exists": true}},
+		}}},
+		bson.M{"$count": "n"},
+	}
+
+	cursor, err := client.Database("admin").Aggregate(ctx, pipeline)
+	if err != nil {
+		return 0, err
+	}
+
+	var res []struct {
+		N int `bson:"n"`
+	}
+	if err := cursor.All(ctx, &res); err != nil {
+		return 0, err
+	}
+
+	if len(res) == 0 {
+		return 0, nil
+	}
+
+	return res[0].N, nil
+}
+
+// balancerStatusMetrics makes the metrics of the balancerStatus command.
+func balancerStatusMetrics(status *balancerStatus, labels map[string]string) []prometheus.Metric {
+	modeDesc := prometheus.NewDesc("mongodb_balancer_mode_info", "Mode of the balancer (full or off)", []string{"mode"}, labels)
+	inRoundDesc := prometheus.NewDesc("mongodb_balancer_in_round", "1 if the balancer is running a balancing round", nil, labels)
+	roundsDesc := prometheus.NewDesc("mongodb_balancer_rounds_total", "Number of balancing rounds since the config server primary started", nil, labels)
+
+	inRound := 0.0
+	if status.InBalancerRound {
+		inRound = 1
+	}
+
+	return []prometheus.Metric{
+		prometheus.MustNewConstMetric(modeDesc, prometheus.GaugeValue, 1, status.Mode),
+		prometheus.MustNewConstMetric(inRoundDesc, prometheus.GaugeValue, inRound),
+		prometheus.MustNewConstMetric(roundsDesc, prometheus.CounterValue, float64(status.NumBalancerRounds)),
+	}
+}
+
+// balancerSettingsMetrics makes the metrics of the balancer settings. The window
+// bounds are reported as seconds since midnight, in the time zone of the config
+// servers.
+func balancerSettingsMetrics(settings *balancerSettings, labels map[string]string) []prometheus.Metric {
+	gauge := func(name, help string, value float64) prometheus.Metric {
+		desc := prometheus.NewDesc("mongodb_balancer_"+name, help, nil, labels)
+		return prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, value)
+	}
+
+	stopped := 0.0
+	if settings.Stopped {
+		stopped = 1
+	}
+
+	windowConfigured := 0.0
+	var metrics []prometheus.Metric
+
+	if w := settings.ActiveWindow; w != nil {
+		start, startOK := clockSeconds(w.Start)
+		stop, stopOK := clockSeconds(w.Stop)
+		if startOK && stopOK {
+			windowConfigured = 1
+			metrics = append(metrics,
+				gauge("window_start_seconds", "Start of the balancer window, in seconds since midnight", start),
+				gauge("window_stop_seconds", "End of the balancer window, in seconds since midnight", stop),
+			)
+		}
+	}
+
+	return append(metrics,
+		gauge("stopped", "1 if the balancer is stopped in the balancer settings", stopped),
+		gauge("window_configured", "1 if the balancer only runs in an active window", windowConfigured),
+	)
+}
+
+// clockSeconds parses a HH:MM time of the day and returns the seconds since midnight.
+func clockSeconds(s string) (float64, bool) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, false
+	}
+
+	return (time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute).Seconds(), true
+}
+
+var _ prometheus.Collector = (*balancerCollector)(nil)
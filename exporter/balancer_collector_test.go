@@ -0,0 +1,111 @@
+// mongodb_exporter
+// Copyright (C) 2017 Percona LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/promslog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/percona/mongodb_exporter/internal/tu"
+)
+
+func TestBalancerCollector(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	client := tu.DefaultTestClientMongoS(ctx, t)
+
+	c := newBalancerCollector(ctx, client, promslog.New(&promslog.Config{}), labelsGetterMock{}, newBalancerTracker())
+
+	for _, name := range []string{
+		"mongodb_balancer_mode_info",
+		"mongodb_balancer_in_round",
+		"mongodb_balancer_migrations_in_progress",
+		"mongodb_balancer_window_configured",
+	} {
+		assert.Equal(t, 1, testutil.CollectAndCount(c, name), name)
+	}
+}
+
+func TestBalancerTracker(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tracker := newBalancerTracker()
+	tracker.hwm = start
+	tracker.observe([]changelogEvent{
+		{ID: "a", What: "moveChunk.commit", Time: start},
+		{ID: "b", What: "moveChunk.from", Time: start.Add(time.Second), Details: bson.M{
+			"step 1 of 6": int32(2),
+			"step 2 of 6": int64(150),
+			"note":        "success",
+		}},
+		{ID: "c", What: "moveRange.error", Time: start.Add(time.Second)},
+	})
+
+	// The events at the high-water mark are read again by the next read.
+	tracker.observe([]changelogEvent{
+		{ID: "b", What: "moveChunk.from", Time: start.Add(time.Second)},
+		{ID: "c", What: "moveRange.error", Time: start.Add(time.Second)},
+		{ID: "d", What: "moveChunk.commit", Time: start.Add(time.Second)},
+	})
+
+	expected := strings.NewReader(`
+	# HELP mongodb_balancer_migrations_total Number of committed and aborted chunk migrations read from config.changelog since the exporter started
+	# TYPE mongodb_balancer_migrations_total counter
+	mongodb_balancer_migrations_total{operation="moveChunk",result="commit"} 2
+	mongodb_balancer_migrations_total{operation="moveRange",result="abort"} 1` + "\n")
+	require.NoError(t, testutil.CollectAndCompare(tracker.migrations, expected))
+	assert.Equal(t, 2, testutil.CollectAndCount(tracker.steps))
+	assert.Equal(t, start.Add(time.Second), tracker.hwm)
+}
+
+func TestBalancerSettingsMetrics(t *testing.T) {
+	t.Parallel()
+
+	settings := &balancerSettings{
+		Stopped:      true,
+		ActiveWindow: &balancerWindow{Start: "23:30", Stop: "6:00"},
+	}
+
+	expected := strings.NewReader(`
+	# HELP mongodb_balancer_stopped 1 if the balancer is stopped in the balancer settings
+	# TYPE mongodb_balancer_stopped gauge
+	mongodb_balancer_stopped 1
+	# HELP mongodb_balancer_window_configured 1 if the balancer only runs in an active window
+	# TYPE mongodb_balancer_window_configured gauge
+	mongodb_balancer_window_configured 1
+	# HELP mongodb_balancer_window_start_seconds Start of the balancer window, in seconds since midnight
+	# TYPE mongodb_balancer_window_start_seconds gauge
+	mongodb_balancer_window_start_seconds 84600
+	# HELP mongodb_balancer_window_stop_seconds End of the balancer window, in seconds since midnight
+	# TYPE mongodb_balancer_window_stop_seconds gauge
+	mongodb_balancer_window_stop_seconds 21600` + "\n")
+	require.NoError(t, testutil.CollectAndCompare(metricsCollector(balancerSettingsMetrics(settings, nil)), expected))
+
+	// An invalid window is reported as not configured.
+	settings.ActiveWindow.Stop = "25:00"
+	assert.Len(t, balancerSettingsMetrics(settings, nil), 2)
+}
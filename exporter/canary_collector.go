@@ -0,0 +1,468 @@
+// mongodb_exporter
+// Copyright (C) 2017 Percona LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readconcern"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
+
+	"github.com/percona/mongodb_exporter/exporter/dsn_fix"
+	"github.com/percona/mongodb_exporter/internal/proto"
+	"github.com/percona/mongodb_exporter/internal/util"
+)
+
+const (
+	// DefaultCanaryDatabase is the database of the canary heartbeat documents.
+	DefaultCanaryDatabase = "mongodb_exporter_canary"
+	// DefaultCanaryInterval is the time between two runs of the canary probe.
+	DefaultCanaryInterval = 10 * time.Second
+
+	canaryCollection = "heartbeat"
+	// How often a secondary is polled until the heartbeat is visible on it.
+	canaryPollInterval = 10 * time.Millisecond
+	// Longest wait for the heartbeat to be visible on the secondaries.
+	canaryVisibilityTimeout = 5 * time.Second
+)
+
+// Operations of the canary errors counter.
+const (
+	canaryOpWrite      = "write"
+	canaryOpRead       = "read"
+	canaryOpVisibility = "visibility"
+)
+
+// canaryProbe runs the canary probe on its own interval, independently of the
+// scrapes, and keeps the connections to the replica set members and the latency
+// histograms between runs. The scrapes of the primary only update the members to
+// probe.
+type canaryProbe struct {
+	uri            string
+	database       string
+	interval       time.Duration
+	connectTimeout time.Duration
+	logger         *slog.Logger
+
+	// membersMu guards the members to probe and the state of the loop.
+	membersMu   sync.Mutex
+	primary     string // Empty while the target is not the primary.
+	secondaries []string
+	cancel      context.CancelFunc // Stops the loop, nil until it is started.
+	done        chan struct{}      // Closed when the loop returns.
+	stopped     bool
+
+	// mu serializes the probes and guards the fields below.
+	mu      sync.Mutex
+	clients map[string]*mongo.Client // Direct connections by member name.
+	seq     int64
+
+	writes     *prometheus.HistogramVec
+	reads      *prometheus.HistogramVec
+	visibility *prometheus.HistogramVec
+	errors     *prometheus.CounterVec
+}
+
+func newCanaryProbe(opts *Opts, logger *slog.Logger) *canaryProbe {
+	uri := opts.CanaryURI
+
+	database := opts.CanaryDatabase
+	if database == "" {
+		database = DefaultCanaryDatabase
+	}
+
+	interval := opts.CanaryInterval
+	if interval <= 0 {
+		interval = DefaultCanaryInterval
+	}
+
+	// 1ms to ~16s.
+	buckets := prometheus.ExponentialBuckets(0.001, 2, 15) //nolint:mnd
+
+	return &canaryProbe{
+		uri:            uri,
+		database:       database,
+		interval:       interval,
+		connectTimeout: time.Duration(opts.ConnectTimeoutMS) * time.Millisecond,
+		logger:         logger.With("component", "canary"),
+		clients:        make(map[string]*mongo.Client),
+
+		writes: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "mongodb_canary_write_duration_seconds",
+			Help:    "Latency of the canary heartbeat upserts on the primary, by write concern",
+			Buckets: buckets,
+		}, []string{"member", "write_concern"}),
+		reads: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "mongodb_canary_read_duration_seconds",
+			Help:    "Latency of the canary heartbeat reads on the primary following the w:majority upsert, by read concern",
+			Buckets: buckets,
+		}, []string{"member", "read_concern"}),
+		visibility: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "mongodb_canary_replication_visibility_seconds",
+			Help:    "Time from the start of the canary w:1 upsert until the heartbeat is visible on the secondary",
+			Buckets: buckets,
+		}, []string{"member"}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "mongodb_canary_errors_total",
+			Help: "Number of failed or timed out canary operations, by member and operation",
+		}, []string{"member", "operation"}),
+	}
+}
+
+// client returns a direct connection to the member, using the credentials of the
+// canary URI.
+func (p *canaryProbe) client(ctx context.Context, member string) (*mongo.Client, error) {
+	if client, ok := p.clients[member]; ok {
+		return client, nil
+	}
+
+	clientOpts, err := dsn_fix.ClientOptionsForDSN(p.uri)
+	if err != nil {
+		return nil, fmt.Errorf("invalid canary dsn: %w", err)
+	}
+
+	clientOpts.SetHosts([]string{member})
+	clientOpts.SetDirect(true)
+	clientOpts.SetAppName("mongodb_exporter")
+	if clientOpts.ConnectTimeout == nil {
+		clientOpts.SetConnectTimeout(p.connectTimeout)
+		clientOpts.SetServerSelectionTimeout(p.connectTimeout)
+	}
+
+	client, err := mongo.Connect(ctx, clientOpts)
+	if err != nil {
+		return nil, fmt.Errorf("cannot connect to %s: %w", member, err)
+	}
+
+	p.clients[member] = client
+
+	return client, nil
+}
+
+// forget closes the connections to the members not in members.
+func (p *canaryProbe) forget(ctx context.Context, members map[string]bool) {
+	for member, client := range p.clients {
+		if members[member] {
+			continue
+		}
+
+		if err := client.Disconnect(ctx); err != nil {
+			p.logger.Debug("cannot disconnect", "member", member, "error", err)
+		}
+		delete(p.clients, member)
+	}
+}
+
+// setMembers sets the members probed by the next runs and starts the loop the first
+// time a primary is set. An empty primary pauses the probe.
+func (p *canaryProbe) setMembers(primary string, secondaries []string) {
+	p.membersMu.Lock()
+	defer p.membersMu.Unlock()
+
+	p.primary = primary
+	p.secondaries = secondaries
+
+	if primary == "" || p.cancel != nil || p.stopped {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p.cancel = cancel
+	p.done = make(chan struct{})
+
+	go p.loop(ctx, p.done)
+}
+
+// loop runs the probe every interval until ctx is canceled.
+func (p *canaryProbe) loop(ctx context.Context, done chan struct{}) {
+	defer close(done)
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		p.membersMu.Lock()
+		primary, secondaries := p.primary, p.secondaries
+		p.membersMu.Unlock()
+
+		if primary != "" {
+			// A run must not overlap the next one.
+			runCtx, cancel := context.WithTimeout(ctx, p.interval)
+			p.run(runCtx, primary, secondaries)
+			cancel()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// stop stops the loop and closes the connections to the members. The probe is not
+// restarted afterwards.
+func (p *canaryProbe) stop(ctx context.Context) {
+	p.membersMu.Lock()
+	p.stopped = true
+	cancel, done := p.cancel, p.done
+	p.membersMu.Unlock()
+
+	if cancel != nil {
+		cancel()
+		<-done
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.forget(ctx, nil)
+}
+
+// run upserts the heartbeat on the primary at w:1 and measures when it becomes
+// visible on each secondary, then upserts it at w:majority and reads it back with
+// a majority read concern.
+func (p *canaryProbe) run(ctx context.Context, primary string, secondaries []string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	members := map[string]bool{primary: true}
+	for _, s := range secondaries {
+		members[s] = true
+	}
+	p.forget(ctx, members)
+
+	client, err := p.client(ctx, primary)
+	if err != nil {
+		p.logger.Error("cannot connect to the primary", "error", err)
+		p.errors.WithLabelValues(primary, canaryOpWrite).Inc()
+
+		return
+	}
+
+	// One heartbeat document per primary, so exporters of different replica sets
+	// can share the database.
+	id := primary
+
+	p.seq++
+	seq := p.seq
+	start := time.Now()
+	if err := p.upsert(ctx, client, writeconcern.W1(), id, seq); err != nil {
+		p.logger.Error("cannot write the canary heartbeat", "write_concern", "1", "error", err)
+		p.errors.WithLabelValues(primary, canaryOpWrite).Inc()
+
+		return
+	}
+	p.writes.WithLabelValues(primary, "1").Observe(time.Since(start).Seconds())
+
+	var wg sync.WaitGroup
+	for _, member := range secondaries {
+		secondary, err := p.client(ctx, member)
+		if err != nil {
+			p.logger.Error("cannot connect to the secondary", "member", member, "error", err)
+			p.errors.WithLabelValues(member, canaryOpVisibility).Inc()
+
+			continue
+		}
+
+		wg.Add(1)
+		go func(member string, secondary *mongo.Client) {
+			defer wg.Done()
+
+			if err := p.waitVisible(ctx, secondary, id, seq); err != nil {
+				p.logger.Warn("canary heartbeat not visible", "member", member, "error", err)
+				p.errors.WithLabelValues(member, canaryOpVisibility).Inc()
+
+				return
+			}
+			p.visibility.WithLabelValues(member).Observe(time.Since(start).Seconds())
+		}(member, secondary)
+	}
+	wg.Wait()
+
+	p.seq++
+	start = time.Now()
+	if err := p.upsert(ctx, client, writeconcern.Majority(), id, p.seq); err != nil {
+		p.logger.Error("cannot write the canary heartbeat", "write_concern", "majority", "error", err)
+		p.errors.WithLabelValues(primary, canaryOpWrite).Inc()
+
+		return
+	}
+	p.writes.WithLabelValues(primary, "majority").Observe(time.Since(start).Seconds())
+
+	start = time.Now()
+	coll := client.Database(p.database, options.Database().SetReadConcern(readconcern.Majority())).Collection(canaryCollection)
+	if err := coll.FindOne(ctx, bson.D{{Key: "_id", Value: id}}).Err(); err != nil {
+		p.logger.Error("cannot read the canary heartbeat", "read_concern", "majority", "error", err)
+		p.errors.WithLabelValues(primary, canaryOpRead).Inc()
+
+		return
+	}
+	p.reads.WithLabelValues(primary, "majority").Observe(time.Since(start).Seconds())
+}
+
+func (p *canaryProbe) upsert(ctx context.Context, client *mongo.Client, wc *writeconcern.WriteConcern, id string, seq int64) error {
+	coll := client.Database(p.database, options.Database().SetWriteConcern(wc)).Collection(canaryCollection)
+	update := bson.D{{Key: "$set", Value: bson.D{
+		{Key: "seq", Value: seq},
+		{Key: "ts", Value: time.Now()},
+	}}}
+
+	_, err := coll.UpdateOne(ctx, bson.D{{Key: "_id", Value: id}}, update, options.Update().SetUpsert(true))
+
+	return err
+}
+
+// waitVisible polls the member until it returns the heartbeat with a sequence
+// number of at least seq, for at most canaryVisibilityTimeout or until the
+// deadline of ctx if it is earlier.
+func (p *canaryProbe) waitVisible(ctx context.Context, client *mongo.Client, id string, seq int64) error {
+	ctx, cancel := context.WithTimeout(ctx, canaryVisibilityTimeout)
+	defer cancel()
+
+	coll := client.Database(p.database).Collection(canaryCollection)
+	filter := bson.D{{Key: "_id", Value: id}, {Key: "seq", Value: bson.D{{Key: "$gte", Value: seq}}}}
+
+	ticker := time.NewTicker(canaryPollInterval)
+	defer ticker.Stop()
+
+	for {
+		err := coll.FindOne(ctx, filter).Err()
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// collect sends the histograms and counters of the probe.
+func (p *canaryProbe) collect(ch chan<- prometheus.Metric) {
+	p.writes.Collect(ch)
+	p.reads.Collect(ch)
+	p.visibility.Collect(ch)
+	p.errors.Collect(ch)
+}
+
+type canaryCollector struct {
+	ctx  context.Context
+	base *baseCollector
+
+	probe *canaryProbe
+}
+
+// newCanaryCollector creates a collector exporting the metrics of the canary probe,
+// and giving it the members to probe when the target is the primary of a replica set.
+func newCanaryCollector(ctx context.Context, client *mongo.Client, logger *slog.Logger, probe *canaryProbe) *canaryCollector {
+	return &canaryCollector{
+		ctx:  ctx,
+		base: newBaseCollector(client, logger.With("collector", "canary")),
+
+		probe: probe,
+	}
+}
+
+func (d *canaryCollector) Describe(ch chan<- *prometheus.Desc) {
+	d.base.Describe(d.ctx, ch, d.collect)
+}
+
+func (d *canaryCollector) Collect(ch chan<- prometheus.Metric) {
+	d.base.Collect(ch)
+}
+
+func (d *canaryCollector) collect(ch chan<- prometheus.Metric) {
+	defer measureCollectTime(ch, "mongodb", "canary")()
+
+	logger := d.base.logger
+	client := d.base.client
+	if client == nil {
+		return
+	}
+
+	status, err := util.ReplicasetStatus(d.ctx, client)
+	if err != nil {
+		// The probe is paused until the target is known to be the primary again.
+		d.probe.setMembers("", nil)
+
+		var e mongo.CommandError
+		if errors.As(err, &e) && util.IsReplicationNotEnabledError(e) {
+			return
+		}
+		logger.Error("cannot get replSetGetStatus", "error", err)
+
+		return
+	}
+
+	rs, err := util.ReplicasetConfig(d.ctx, client)
+	if err != nil {
+		d.probe.setMembers("", nil)
+		logger.Error("cannot get replSetGetConfig", "error", err)
+
+		return
+	}
+
+	d.probe.setMembers(canaryMembers(status, &rs.Config))
+	d.probe.collect(ch)
+}
+
+// canaryMembers returns the name of the member the status was taken from if it is
+// the primary, and the secondaries to probe. Only the exporter of the primary writes
+// the heartbeat, so the primary is empty if the member is not the primary. Delayed
+// secondaries are skipped, the heartbeat is not expected to be visible on them
+// within the visibility timeout.
+func canaryMembers(status *proto.ReplicaSetStatus, config *proto.RSConfig) (string, []string) {
+	delayed := make(map[string]bool, len(config.Members))
+	for _, m := range config.Members {
+		delayed[m.Host] = memberDelaySecs(m) > 0
+	}
+
+	var primary string
+	var secondaries []string
+	for _, m := range status.Members {
+		switch {
+		case m.Self:
+			if m.State == PrimaryState {
+				primary = m.Name
+			}
+		case int(m.State) == SecondaryState && !delayed[m.Name]:
+			secondaries = append(secondaries, m.Name)
+		}
+	}
+
+	if primary == "" {
+		return "", nil
+	}
+
+	return primary, secondaries
+}
+
+var _ prometheus.Collector = (*canaryCollector)(nil)
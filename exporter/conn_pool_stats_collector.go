@@ -0,0 +1,141 @@
+// mongodb_exporter
+// Copyright (C) 2017 Percona LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"context"
+	"log/slog"
+	"maps"
+	"slices"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Document of the acquisition wait time buckets, in the pools and their hosts.
+const connPoolAcquisitionWaitTimes = "acquisitionWaitTimes"
+
+// connPoolField is a counter of a host of a connection pool in connPoolStats.
+type connPoolField struct {
+	field     string
+	name      string
+	help      string
+	valueType prometheus.ValueType
+}
+
+var connPoolFields = []connPoolField{
+	{"inUse", "in_use", "Number of connections to the host in use", prometheus.GaugeValue},
+	{"available", "available", "Number of idle connections to the host", prometheus.GaugeValue},
+	{"leased", "leased", "Number of connections to the host leased out of the pool, like the streaming connections", prometheus.GaugeValue},
+	{"refreshing", "refreshing", "Number of connections to the host being refreshed", prometheus.GaugeValue},
+	{"created", "created_total", "Number of connections to the host created by the pool", prometheus.CounterValue},
+	{"refreshed", "refreshed_total", "Number of connections to the host refreshed by the pool", prometheus.CounterValue},
+	{"wasNeverUsed", "never_used_total", "Number of connections to the host closed without being used", prometheus.CounterValue},
+}
+
+type connPoolStatsCollector struct {
+	ctx  context.Context
+	base *baseCollector
+
+	topologyInfo labelsGetter
+}
+
+// newConnPoolStatsCollector creates a collector for the outgoing connection pools of
+// a mongos or mongod, from connPoolStats.
+func newConnPoolStatsCollector(ctx context.Context, client *mongo.Client, logger *slog.Logger, topology labelsGetter) *connPoolStatsCollector {
+	return &connPoolStatsCollector{
+		ctx:  ctx,
+		base: newBaseCollector(client, logger.With("collector", "conn_pool_stats")),
+
+		topologyInfo: topology,
+	}
+}
+
+func (d *connPoolStatsCollector) Describe(ch chan<- *prometheus.Desc) {
+	d.base.Describe(d.ctx, ch, d.collect)
+}
+
+func (d *connPoolStatsCollector) Collect(ch chan<- prometheus.Metric) {
+	d.base.Collect(ch)
+}
+
+func (d *connPoolStatsCollector) collect(ch chan<- prometheus.Metric) {
+	defer measureCollectTime(ch, "mongodb", "conn_pool_stats")()
+
+	logger := d.base.logger
+	client := d.base.client
+	if client == nil {
+		return
+	}
+
+	var stats struct {
+		Pools map[string]bson.M `bson:"pools"`
+	}
+	if err := client.Database("admin").RunCommand(d.ctx, bson.D{{Key: "connPoolStats", Value: 1}}).Decode(&stats); err != nil {
+		logger.Error("cannot get connPoolStats", "error", err)
+		return
+	}
+
+	for _, metric := range connPoolMetrics(stats.Pools, d.topologyInfo.baseLabels()) {
+		ch <- metric
+	}
+}
+
+// connPoolMetrics makes the metrics of each host of each connection pool, and the
+// number of connection acquisitions by wait time, from the pools of connPoolStats.
+// The pool documents have pool totals and a document per host.
+func connPoolMetrics(pools map[string]bson.M, labels map[string]string) []prometheus.Metric {
+	labelNames := []string{"pool", "host"}
+	descs := make([]*prometheus.Desc, len(connPoolFields))
+	for i, f := range connPoolFields {
+		descs[i] = prometheus.NewDesc("mongodb_conn_pool_"+f.name, f.help, labelNames, labels)
+	}
+	waitDesc := prometheus.NewDesc("mongodb_conn_pool_acquisition_waits_total",
+		"Number of connections to the host acquired from the pool, by wait time bucket", append(labelNames, "wait"), labels)
+
+	var metrics []prometheus.Metric
+	for _, pool := range slices.Sorted(maps.Keys(pools)) {
+		for _, host := range slices.Sorted(maps.Keys(pools[pool])) {
+			stats, ok := pools[pool][host].(bson.M)
+			if !ok || host == connPoolAcquisitionWaitTimes {
+				continue
+			}
+
+			for i, f := range connPoolFields {
+				v, err := asFloat64(stats[f.field])
+				if err != nil || v == nil {
+					continue
+				}
+				metrics = append(metrics, prometheus.MustNewConstMetric(descs[i], f.valueType, *v, pool, host))
+			}
+
+			// Since 6.0, as {"0-50ms": n, ..., "1000+ms": n, "totalCount": n}.
+			waits, _ := stats[connPoolAcquisitionWaitTimes].(bson.M)
+			for bucket, n := range waits {
+				v, err := asFloat64(n)
+				if err != nil || v == nil || bucket == "totalCount" {
+					continue
+				}
+				metrics = append(metrics, prometheus.MustNewConstMetric(waitDesc, prometheus.CounterValue, *v, pool, host, bucket))
+			}
+		}
+	}
+
+	return metrics
+}
+
+var _ prometheus.Collector = (*connPoolStatsCollector)(nil)
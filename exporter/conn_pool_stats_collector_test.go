@@ -0,0 +1,100 @@
+// mongodb_exporter
+// Copyright (C) 2017 Percona LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/promslog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/percona/mongodb_exporter/internal/tu"
+)
+
+func TestConnPoolStatsCollector(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	client := tu.DefaultTestClientMongoS(ctx, t)
+
+	c := newConnPoolStatsCollector(ctx, client, promslog.New(&promslog.Config{}), labelsGetterMock{})
+
+	// mongos keeps connections to the shards and config servers.
+	assert.Positive(t, testutil.CollectAndCount(c, "mongodb_conn_pool_created_total"))
+}
+
+func TestConnPoolMetrics(t *testing.T) {
+	t.Parallel()
+
+	pools := map[string]bson.M{
+		"NetworkInterfaceTL-ShardRegistry": {
+			"poolInUse":     int32(1),
+			"poolAvailable": int32(2),
+			"acquisitionWaitTimes": bson.M{
+				"0-50ms":     int64(10),
+				"totalCount": int64(10),
+			},
+			"rs1:27017": bson.M{
+				"inUse":        int32(1),
+				"available":    int32(2),
+				"leased":       int32(0),
+				"created":      int32(3),
+				"refreshing":   int32(0),
+				"refreshed":    int32(7),
+				"wasNeverUsed": int32(1),
+				"acquisitionWaitTimes": bson.M{
+					"0-50ms":     int64(9),
+					"1000+ms":    int64(1),
+					"totalCount": int64(10),
+				},
+			},
+		},
+	}
+
+	expected := strings.NewReader(`
+	# HELP mongodb_conn_pool_acquisition_waits_total Number of connections to the host acquired from the pool, by wait time bucket
+	# TYPE mongodb_conn_pool_acquisition_waits_total counter
+	mongodb_conn_pool_acquisition_waits_total{host="rs1:27017",pool="NetworkInterfaceTL-ShardRegistry",wait="0-50ms"} 9
+	mongodb_conn_pool_acquisition_waits_total{host="rs1:27017",pool="NetworkInterfaceTL-ShardRegistry",wait="1000+ms"} 1
+	# HELP mongodb_conn_pool_available Number of idle connections to the host
+	# TYPE mongodb_conn_pool_available gauge
+	mongodb_conn_pool_available{host="rs1:27017",pool="NetworkInterfaceTL-ShardRegistry"} 2
+	# HELP mongodb_conn_pool_created_total Number of connections to the host created by the pool
+	# TYPE mongodb_conn_pool_created_total counter
+	mongodb_conn_pool_created_total{host="rs1:27017",pool="NetworkInterfaceTL-ShardRegistry"} 3
+	# HELP mongodb_conn_pool_in_use Number of connections to the host in use
+	# TYPE mongodb_conn_pool_in_use gauge
+	mongodb_conn_pool_in_use{host="rs1:27017",pool="NetworkInterfaceTL-ShardRegistry"} 1
+	# HELP mongodb_conn_pool_leased Number of connections to the host leased out of the pool, like the streaming connections
+	# TYPE mongodb_conn_pool_leased gauge
+	mongodb_conn_pool_leased{host="rs1:27017",pool="NetworkInterfaceTL-ShardRegistry"} 0
+	# HELP mongodb_conn_pool_never_used_total Number of connections to the host closed without being used
+	# TYPE mongodb_conn_pool_never_used_total counter
+	mongodb_conn_pool_never_used_total{host="rs1:27017",pool="NetworkInterfaceTL-ShardRegistry"} 1
+	# HELP mongodb_conn_pool_refreshed_total Number of connections to the host refreshed by the pool
+	# TYPE mongodb_conn_pool_refreshed_total counter
+	mongodb_conn_pool_refreshed_total{host="rs1:27017",pool="NetworkInterfaceTL-ShardRegistry"} 7
+	# HELP mongodb_conn_pool_refreshing Number of connections to the host being refreshed
+	# TYPE mongodb_conn_pool_refreshing gauge
+	mongodb_conn_pool_refreshing{host="rs1:27017",pool="NetworkInterfaceTL-ShardRegistry"} 0` + "\n")
+	require.NoError(t, testutil.CollectAndCompare(metricsCollector(connPoolMetrics(pools, nil)), expected))
+}
@@ -16,6 +16,7 @@
 package exporter
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -28,7 +29,23 @@ func debugResult(log *slog.Logger, m interface{}) {
 		return
 	}
 
-	debugStr, err := json.MarshalIndent(m, "", "  ")
+	buf, err := json.Marshal(m)
+	if err != nil {
+		log.Error("cannot marshal struct for debug", "error", err)
+		return
+	}
+
+	// Decode the result again to mask credentials and secrets like the key file
+	// path or LDAP passwords in getCmdLineOpts, whatever the type of m is.
+	var doc any
+	dec := json.NewDecoder(bytes.NewReader(buf))
+	dec.UseNumber()
+	if err := dec.Decode(&doc); err != nil {
+		log.Error("cannot decode struct for debug", "error", err)
+		return
+	}
+
+	debugStr, err := json.MarshalIndent(redactDocument(doc), "", "  ")
 	if err != nil {
 		log.Error("cannot marshal struct for debug", "error", err)
 		return
@@ -46,7 +46,7 @@ type diagnosticDataCollector struct {
 // newDiagnosticDataCollector creates a collector for diagnostic information.
 func newDiagnosticDataCollector(ctx context.Context, client *mongo.Client, logger *slog.Logger, compatible bool, topology labelsGetter, buildInfo buildInfo, enableHistograms bool) *diagnosticDataCollector {
 	logger = logger.With("component", "diagnosticDataCollector")
-	nodeType, err := getNodeType(ctx, client)
+	nodeType, err := nodeTypeOf(ctx, client, topology)
 	if err != nil {
 		logger.Error("Cannot get node type", "error", err)
 	}
@@ -83,7 +83,7 @@ func (d *diagnosticDataCollector) collect(ch chan<- prometheus.Metric) {
 	logger := d.base.logger
 	client := d.base.client
 
-	nodeType, err := getNodeType(d.ctx, client)
+	nodeType, err := nodeTypeOf(d.ctx, client, d.topologyInfo)
 	if err != nil {
 		logger.Error("Cannot get node type", "error", err)
 	}
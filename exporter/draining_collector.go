@@ -0,0 +1,261 @@
+// mongodb_exporter
+// Copyright (C) 2017 Percona LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// drainingRemaining is what is left on a draining shard, the same counters the
+// removeShard command reports while the removal is ongoing.
+type drainingRemaining struct {
+	Chunks      int64
+	DBs         int64
+	JumboChunks int64
+}
+
+// drainingStart is the first observation of a draining shard.
+type drainingStart struct {
+	time   time.Time
+	chunks int64
+}
+
+// drainingTracker keeps the first observation of each draining shard, to compute the
+// average migration rate since the drain was first seen.
+type drainingTracker struct {
+	mu     sync.Mutex
+	starts map[string]drainingStart
+}
+
+func newDrainingTracker() *drainingTracker {
+	return &drainingTracker{starts: make(map[string]drainingStart)}
+}
+
+// observe records the remaining chunks of the draining shards and returns the
+// number of chunks migrated per second since each drain was first seen. Shards
+// which are not draining anymore are forgotten.
+func (t *drainingTracker) observe(now time.Time, remaining map[string]drainingRemaining) map[string]float64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for shard := range t.starts {
+		if _, ok := remaining[shard]; !ok {
+			delete(t.starts, shard)
+		}
+	}
+
+	rates := make(map[string]float64, len(remaining))
+	for shard, r := range remaining {
+		start, ok := t.starts[shard]
+		// Chunks split on the shard while it drains restart the measure.
+		if !ok || r.Chunks > start.chunks {
+			t.starts[shard] = drainingStart{time: now, chunks: r.Chunks}
+			continue
+		}
+
+		if elapsed := now.Sub(start.time).Seconds(); elapsed > 0 {
+			rates[shard] = float64(start.chunks-r.Chunks) / elapsed
+		}
+	}
+
+	return rates
+}
+
+type drainingCollector struct {
+	ctx  context.Context
+	base *baseCollector
+
+	topologyInfo labelsGetter
+	tracker      *drainingTracker
+}
+
+// newDrainingCollector creates a collector for the progress of the shards being
+// removed from a sharded cluster.
+func newDrainingCollector(ctx context.Context, client *mongo.Client, logger *slog.Logger, topology labelsGetter, tracker *drainingTracker) *drainingCollector {
+	return &drainingCollector{
+		ctx:  ctx,
+		base: newBaseCollector(client, logger.With("collector", "draining")),
+
+		topologyInfo: topology,
+		tracker:      tracker,
+	}
+}
+
+func (d *drainingCollector) Describe(ch chan<- *prometheus.Desc) {
+	d.base.Describe(d.ctx, ch, d.collect)
+}
+
+func (d *drainingCollector) Collect(ch chan<- prometheus.Metric) {
+	d.base.Collect(ch)
+}
+
+func (d *drainingCollector) collect(ch chan<- prometheus.Metric) {
+	defer measureCollectTime(ch, "mongodb", "draining")()
+
+	logger := d.base.logger
+	client := d.base.client
+	if client == nil {
+		return
+	}
+
+	remaining, err := drainingShards(d.ctx, client)
+	if err != nil {
+		logger.Error("cannot get the draining shards", "error", err)
+		return
+	}
+
+	rates := d.tracker.observe(time.Now(), remaining)
+
+	for _, metric := range drainingMetrics(remaining, rates, d.topologyInfo.baseLabels()) {
+		ch <- metric
+	}
+}
+
+// drainingShards returns what is left on each draining shard. It is read from the
+// config collections rather than with removeShard, which commits the removal once
+// the shard is empty and needs the clusterManager role.
+func drainingShards(ctx context.Context, client *mongo.Client) (map[string]drainingRemaining, error) {
+	config := client.Database("config")
+
+	cursor, err := config.Collection("shards").Find(ctx, bson.M{"draining": true})
+	if err != nil {
+		return nil, fmt.Errorf("cannot read config.shards: %w", err)
+	}
+
+	var shards []struct {
+		ID string `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &shards); err != nil {
+		return nil, fmt.Errorf("cannot decode config.shards: %w", err)
+	}
+
+	if len(shards) == 0 {
+		return nil, nil
+	}
+
+	remaining := make(map[string]drainingRemaining, len(shards))
+	ids := make(bson.A, 0, len(shards))
+	for _, shard := range shards {
+		remaining[shard.ID] = drainingRemaining{}
+		ids = append(ids, shard.ID)
+	}
+
+	pipeline := bson.A{
+		bson.M{"$match": bson.M{"shard": bson.M{"$in": ids}}},
+		bson.M{"$group": bson.M{
+			"_id":    "$shard",
+			"chunks": bson.M{"$sum": 1},
+			"jumbo":  bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{"$jumbo", true}}, 1, 0}}},
+		}},
+	}
+	cursor, err = config.Collection("chunks").Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("cannot count the chunks of the draining shards: %w", err)
+	}
+
+	var chunks []struct {
+		Shard  string `bson:"_id"`
+		Chunks int64  `bson:"chunks"`
+		Jumbo  int64  `bson:"jumbo"`
+	}
+	if err := cursor.All(ctx, &chunks); err != nil {
+		return nil, fmt.Errorf("cannot decode the chunks of the draining shards: %w", err)
+	}
+
+	for _, c := range chunks {
+		r := remaining[c.Shard]
+		r.Chunks, r.JumboChunks = c.Chunks, c.Jumbo
+		remaining[c.Shard] = r
+	}
+
+	pipeline = bson.A{
+		bson.M{"$match": bson.M{"primary": bson.M{"$in": ids}}},
+		bson.M{"$group": bson.M{"_id": "$primary", "dbs": bson.M{"$sum": 1}}},
+	}
+	cursor, err = config.Collection("databases").Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("cannot count the databases of the draining shards: %w", err)
+	}
+
+	var dbs []struct {
+		Shard string `bson:"_id"`
+		DBs   int64  `bson:"dbs"`
+	}
+	if err := cursor.All(ctx, &dbs); err != nil {
+		return nil, fmt.Errorf("cannot decode the databases of the draining shards: %w", err)
+	}
+
+	for _, d := range dbs {
+		r := remaining[d.Shard]
+		r.DBs = d.DBs
+		remaining[d.Shard] = r
+	}
+
+	return remaining, nil
+}
+
+// drainingMetrics makes the progress metrics of the draining shards. The ETA is the
+// remaining chunks at the average migration rate since the drain was first seen.
+func drainingMetrics(remaining map[string]drainingRemaining, rates map[string]float64, labels map[string]string) []prometheus.Metric {
+	gauge := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc("mongodb_shards_draining_"+name, help, []string{"shard"}, labels)
+	}
+
+	chunksDesc := gauge("remaining_chunks", "Number of chunks left to migrate off the draining shard")
+	jumboDesc := gauge("remaining_jumbo_chunks", "Number of jumbo chunks left on the draining shard, which the balancer cannot migrate")
+	dbsDesc := gauge("remaining_databases", "Number of databases whose primary shard is the draining shard and must be moved with movePrimary")
+	rateDesc := gauge("migration_rate_chunks_per_second", "Average number of chunks migrated off the draining shard per second since the exporter saw the drain start")
+	etaDesc := gauge("eta_seconds", "Rough time left to migrate the remaining chunks off the draining shard, at the average migration rate")
+
+	shards := make([]string, 0, len(remaining))
+	for shard := range remaining {
+		shards = append(shards, shard)
+	}
+	sort.Strings(shards)
+
+	var metrics []prometheus.Metric
+	for _, shard := range shards {
+		r := remaining[shard]
+		metrics = append(metrics,
+			prometheus.MustNewConstMetric(chunksDesc, prometheus.GaugeValue, float64(r.Chunks), shard),
+			prometheus.MustNewConstMetric(jumboDesc, prometheus.GaugeValue, float64(r.JumboChunks), shard),
+			prometheus.MustNewConstMetric(dbsDesc, prometheus.GaugeValue, float64(r.DBs), shard),
+		)
+
+		rate, ok := rates[shard]
+		if !ok {
+			continue
+		}
+		metrics = append(metrics, prometheus.MustNewConstMetric(rateDesc, prometheus.GaugeValue, rate, shard))
+
+		if rate > 0 {
+			metrics = append(metrics, prometheus.MustNewConstMetric(etaDesc, prometheus.GaugeValue, float64(r.Chunks)/rate, shard))
+		}
+	}
+
+	return metrics
+}
+
+var _ prometheus.Collector = (*drainingCollector)(nil)
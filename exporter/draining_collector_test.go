@@ -0,0 +1,74 @@
+// mongodb_exporter
+// Copyright (C) 2017 Percona LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDrainingTracker(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	tracker := newDrainingTracker()
+
+	// No rate until the drain was seen twice.
+	assert.Empty(t, tracker.observe(start, map[string]drainingRemaining{"rs2": {Chunks: 100}}))
+
+	rates := tracker.observe(start.Add(100*time.Second), map[string]drainingRemaining{"rs2": {Chunks: 50}})
+	assert.InDelta(t, 0.5, rates["rs2"], 0)
+
+	// A shard that stopped draining is forgotten and starts over.
+	assert.Empty(t, tracker.observe(start.Add(200*time.Second), nil))
+	assert.Empty(t, tracker.observe(start.Add(300*time.Second), map[string]drainingRemaining{"rs2": {Chunks: 10}}))
+}
+
+func TestDrainingMetrics(t *testing.T) {
+	t.Parallel()
+
+	remaining := map[string]drainingRemaining{
+		"rs2": {Chunks: 50, DBs: 1, JumboChunks: 2},
+		"rs3": {Chunks: 7},
+	}
+	rates := map[string]float64{"rs2": 0.5}
+
+	expected := strings.NewReader(`
+	# HELP mongodb_shards_draining_eta_seconds Rough time left to migrate the remaining chunks off the draining shard, at the average migration rate
+	# TYPE mongodb_shards_draining_eta_seconds gauge
+	mongodb_shards_draining_eta_seconds{shard="rs2"} 100
+	# HELP mongodb_shards_draining_migration_rate_chunks_per_second Average number of chunks migrated off the draining shard per second since the exporter saw the drain start
+	# TYPE mongodb_shards_draining_migration_rate_chunks_per_second gauge
+	mongodb_shards_draining_migration_rate_chunks_per_second{shard="rs2"} 0.5
+	# HELP mongodb_shards_draining_remaining_chunks Number of chunks left to migrate off the draining shard
+	# TYPE mongodb_shards_draining_remaining_chunks gauge
+	mongodb_shards_draining_remaining_chunks{shard="rs2"} 50
+	mongodb_shards_draining_remaining_chunks{shard="rs3"} 7
+	# HELP mongodb_shards_draining_remaining_databases Number of databases whose primary shard is the draining shard and must be moved with movePrimary
+	# TYPE mongodb_shards_draining_remaining_databases gauge
+	mongodb_shards_draining_remaining_databases{shard="rs2"} 1
+	mongodb_shards_draining_remaining_databases{shard="rs3"} 0
+	# HELP mongodb_shards_draining_remaining_jumbo_chunks Number of jumbo chunks left on the draining shard, which the balancer cannot migrate
+	# TYPE mongodb_shards_draining_remaining_jumbo_chunks gauge
+	mongodb_shards_draining_remaining_jumbo_chunks{shard="rs2"} 2
+	mongodb_shards_draining_remaining_jumbo_chunks{shard="rs3"} 0` + "\n")
+	require.NoError(t, testutil.CollectAndCompare(metricsCollector(drainingMetrics(remaining, rates, nil)), expected))
+}
@@ -22,13 +22,16 @@ import (
 	"log/slog"
 	"net/http"
 	_ "net/http/pprof"
+	"slices"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/prometheus/common/promslog"
+	"go.mongodb.org/mongo-driver/event"
 	"go.mongodb.org/mongo-driver/mongo"
 
 	"github.com/percona/mongodb_exporter/exporter/dsn_fix"
@@ -38,10 +41,23 @@ import (
 type Exporter struct {
 	client                *mongo.Client
 	clientMu              sync.Mutex
+	closed                bool // Set by Close, guarded by clientMu.
 	logger                *slog.Logger
 	opts                  *Opts
 	lock                  *sync.Mutex
 	totalCollectionsCount int
+	topology              *topologyCache
+	rollbacks             *rollbackTracker
+	configVersions        *configVersionTracker
+	canary                *canaryProbe
+	oplogOps              *oplogOpsTracker
+	balancer              *balancerTracker
+	draining              *drainingTracker
+
+	// Requests being served, so a removed target is closed once they are done.
+	scrapesMu sync.Mutex
+	scrapes   int
+	idle      chan struct{}
 }
 
 // Opts holds new exporter options.
@@ -69,6 +85,17 @@ type Opts struct {
 	EnableShards                   bool
 	EnableFCV                      bool // Feature Compatibility Version.
 	EnablePBMMetrics               bool
+	EnableOplog                    bool
+	EnableReplication              bool
+	EnableReplicationEvents        bool
+	EnableCanary                   bool
+	EnableOplogOps                 bool
+	EnableBalancer                 bool
+	EnableMongosInventory          bool
+	EnableDraining                 bool
+	EnableRangeDeletions           bool
+	EnableResharding               bool
+	EnableConnPoolStats            bool
 
 	EnableOverrideDescendingIndex bool
 
@@ -80,6 +107,36 @@ type Opts struct {
 	IndexStatsCollections  []string
 	CurrentOpSlowTime      string
 	ProfileTimeTS          int
+	// Member tag a member should share with its sync source, like the data center.
+	SyncSourceTag string
+	// Largest difference between the replication lag and the configured delay of a
+	// delayed member for it to be compliant.
+	ReplicationDelayTolerance time.Duration
+	// URI used by the canary probe, with a user having only the readWrite role on
+	// CanaryDatabase. The hosts are replaced by the replica set members. Required by
+	// EnableCanary: the monitoring user of URI must not be able to write.
+	CanaryURI      string
+	CanaryDatabase string
+	// Time between two runs of the canary probe. Defaults to DefaultCanaryInterval.
+	CanaryInterval time.Duration
+	// Most namespaces counted by the oplog ops collector, the others are counted together.
+	OplogOpsMaxNamespaces int
+	// Most chunks measured with dataSize per scrape by the shards collector. 0 = disabled.
+	ShardsChunkSizeSamples int
+	// Data size from which the shards collector counts an unsharded collection as large. 0 = disabled.
+	ShardsLargeUnshardedBytes int64
+	// Ping age after which a mongos of config.mongos is reported as stale.
+	MongosStaleThreshold time.Duration
+
+	// How long the topology info is cached. It is reloaded earlier when the driver
+	// reports a server state change, which without GlobalConnPool only happens during
+	// scrapes. Zero disables the cache.
+	TopologyCacheTTL time.Duration
+
+	// Static labels added to every metric of this target.
+	ExternalLabels map[string]string
+	// Names of the replica set member tags added as labels to every metric.
+	MemberTagLabels []string
 
 	Logger *slog.Logger
 
@@ -90,6 +147,7 @@ type Opts struct {
 var (
 	errCannotHandleType   = fmt.Errorf("don't know how to handle data type")
 	errUnexpectedDataType = fmt.Errorf("unexpected data type")
+	errExporterClosed     = fmt.Errorf("exporter is closed")
 )
 
 const (
@@ -106,6 +164,12 @@ func New(opts *Opts) *Exporter {
 		promslogConfig := &promslog.Config{}
 		opts.Logger = promslog.New(promslogConfig)
 	}
+	opts.Logger = slog.New(NewRedactingHandler(opts.Logger.Handler()))
+
+	if opts.EnableCanary && opts.CanaryURI == "" {
+		opts.Logger.Error("The canary probe needs a canary URI, it is disabled")
+		opts.EnableCanary = false
+	}
 
 	ctx := context.Background()
 
@@ -114,6 +178,13 @@ func New(opts *Opts) *Exporter {
 		opts:                  opts,
 		lock:                  &sync.Mutex{},
 		totalCollectionsCount: -1, // Not calculated yet. waiting the db connection.
+		topology:              newTopologyCache(opts, opts.Logger),
+		rollbacks:             &rollbackTracker{},
+		configVersions:        &configVersionTracker{},
+		canary:                newCanaryProbe(opts, opts.Logger),
+		oplogOps:              newOplogOpsTracker(opts.OplogOpsMaxNamespaces),
+		balancer:              newBalancerTracker(),
+		draining:              newDrainingTracker(),
 	}
 	// Try initial connect. Connection will be retried with every scrape.
 	go func() {
@@ -135,8 +206,10 @@ func (e *Exporter) getTotalCollectionsCount() int {
 
 func (e *Exporter) makeRegistry(ctx context.Context, client *mongo.Client, topologyInfo labelsGetter, requestOpts Opts) *prometheus.Registry {
 	registry := prometheus.NewRegistry()
+	// All the collectors are registered through the wrapper to get the external labels.
+	registerer := prometheus.WrapRegistererWith(e.externalLabels(topologyInfo), registry)
 
-	nodeType, err := getNodeType(ctx, client)
+	nodeType, err := nodeTypeOf(ctx, client, topologyInfo)
 	if err != nil {
 		e.logger.Error("Registry - Cannot get node type", "error", err)
 	}
@@ -147,7 +220,7 @@ func (e *Exporter) makeRegistry(ctx context.Context, client *mongo.Client, topol
 	}
 
 	gc := newGeneralCollector(ctx, client, nodeType, e.opts.Logger)
-	registry.MustRegister(gc)
+	registerer.MustRegister(gc, e.topology)
 
 	// Enable collectors like collstats and indexstats depending on the number of collections
 	// present in the database.
@@ -174,6 +247,17 @@ func (e *Exporter) makeRegistry(ctx context.Context, client *mongo.Client, topol
 		e.opts.EnableShards = true
 		e.opts.EnableFCV = true
 		e.opts.EnablePBMMetrics = true
+		e.opts.EnableOplog = true
+		e.opts.EnableReplication = true
+		e.opts.EnableReplicationEvents = true
+		e.opts.EnableBalancer = true
+		e.opts.EnableMongosInventory = true
+		e.opts.EnableDraining = true
+		e.opts.EnableRangeDeletions = true
+		e.opts.EnableResharding = true
+		e.opts.EnableConnPoolStats = true
+		// The canary probe writes to the database and the oplog ops collector tails
+		// the whole oplog, they must be enabled explicitly.
 	}
 
 	// arbiter only have isMaster privileges
@@ -189,6 +273,17 @@ func (e *Exporter) makeRegistry(ctx context.Context, client *mongo.Client, topol
 		e.opts.EnableShards = false
 		e.opts.EnableFCV = false
 		e.opts.EnablePBMMetrics = false
+		e.opts.EnableOplog = false
+		e.opts.EnableReplication = false
+		e.opts.EnableReplicationEvents = false
+		e.opts.EnableCanary = false
+		e.opts.EnableOplogOps = false
+		e.opts.EnableBalancer = false
+		e.opts.EnableMongosInventory = false
+		e.opts.EnableDraining = false
+		e.opts.EnableRangeDeletions = false
+		e.opts.EnableResharding = false
+		e.opts.EnableConnPoolStats = false
 	}
 
 	// If we manually set the collection names we want or auto discovery is set.
@@ -196,7 +291,7 @@ func (e *Exporter) makeRegistry(ctx context.Context, client *mongo.Client, topol
 		cc := newCollectionStatsCollector(ctx, client, e.opts.Logger,
 			e.opts.DiscoveringMode,
 			topologyInfo, e.opts.CollStatsNamespaces, e.opts.CollStatsEnableDetails)
-		registry.MustRegister(cc)
+		registerer.MustRegister(cc)
 	}
 
 	// If we manually set the collection names we want or auto discovery is set.
@@ -204,76 +299,262 @@ func (e *Exporter) makeRegistry(ctx context.Context, client *mongo.Client, topol
 		ic := newIndexStatsCollector(ctx, client, e.opts.Logger,
 			e.opts.DiscoveringMode, e.opts.EnableOverrideDescendingIndex,
 			topologyInfo, e.opts.IndexStatsCollections)
-		registry.MustRegister(ic)
+		registerer.MustRegister(ic)
 	}
 
 	if e.opts.EnableDiagnosticData && requestOpts.EnableDiagnosticData {
 		ddc := newDiagnosticDataCollector(ctx, client, e.opts.Logger,
 			e.opts.CompatibleMode, topologyInfo, dbBuildInfo, e.opts.EnableDiagnosticDataHistograms)
-		registry.MustRegister(ddc)
+		registerer.MustRegister(ddc)
 	}
 
 	if e.opts.EnableDBStats && limitsOk && requestOpts.EnableDBStats {
 		cc := newDBStatsCollector(ctx, client, e.opts.Logger,
 			e.opts.CompatibleMode, topologyInfo, nil, e.opts.EnableDBStatsFreeStorage)
-		registry.MustRegister(cc)
+		registerer.MustRegister(cc)
 	}
 
 	if e.opts.EnableCurrentopMetrics && nodeType != typeMongos && requestOpts.EnableCurrentopMetrics {
 		coc := newCurrentopCollector(ctx, client, e.opts.Logger,
 			e.opts.CompatibleMode, topologyInfo, e.opts.CurrentOpSlowTime)
-		registry.MustRegister(coc)
+		registerer.MustRegister(coc)
 	}
 
 	if e.opts.EnableProfile && nodeType != typeMongos && limitsOk && requestOpts.EnableProfile && e.opts.ProfileTimeTS != 0 {
 		pc := newProfileCollector(ctx, client, e.opts.Logger,
 			e.opts.CompatibleMode, topologyInfo, e.opts.ProfileTimeTS)
-		registry.MustRegister(pc)
+		registerer.MustRegister(pc)
 	}
 
 	if e.opts.EnableTopMetrics && nodeType != typeMongos && limitsOk && requestOpts.EnableTopMetrics {
 		tc := newTopCollector(ctx, client, e.opts.Logger, topologyInfo)
-		registry.MustRegister(tc)
+		registerer.MustRegister(tc)
 	}
 
 	// replSetGetStatus is not supported through mongos.
 	if e.opts.EnableReplicasetStatus && nodeType != typeMongos && requestOpts.EnableReplicasetStatus {
 		rsgsc := newReplicationSetStatusCollector(ctx, client, e.opts.Logger,
 			e.opts.CompatibleMode, topologyInfo)
-		registry.MustRegister(rsgsc)
+		registerer.MustRegister(rsgsc)
 	}
 
 	// replSetGetStatus is not supported through mongos.
 	if e.opts.EnableReplicasetConfig && nodeType != typeMongos && requestOpts.EnableReplicasetConfig {
 		rsgsc := newReplicationSetConfigCollector(ctx, client, e.opts.Logger,
-			e.opts.CompatibleMode, topologyInfo)
-		registry.MustRegister(rsgsc)
+			e.opts.CompatibleMode, topologyInfo, e.configVersions)
+		registerer.MustRegister(rsgsc)
 	}
+	// replSetGetStatus is not supported through mongos.
+	if e.opts.EnableReplication && nodeType != typeMongos && requestOpts.EnableReplication {
+		rc := newReplicationCollector(ctx, client, e.opts.Logger, topologyInfo, e.opts.SyncSourceTag, e.opts.ReplicationDelayTolerance)
+		registerer.MustRegister(rc)
+	}
+
+	if e.opts.EnableReplicationEvents && nodeType != typeMongos && requestOpts.EnableReplicationEvents {
+		rec := newReplicationEventsCollector(ctx, client, e.opts.Logger, topologyInfo, e.rollbacks)
+		registerer.MustRegister(rec)
+	}
+
+	if e.opts.EnableCanary && nodeType != typeMongos && requestOpts.EnableCanary {
+		cc := newCanaryCollector(ctx, client, e.opts.Logger, e.canary)
+		registerer.MustRegister(cc)
+	}
+
 	if e.opts.EnableShards && nodeType == typeMongos && requestOpts.EnableShards {
-		sc := newShardsCollector(ctx, client, e.opts.Logger, e.opts.CompatibleMode)
-		registry.MustRegister(sc)
+		// The data size per shard is read with $collStats for each collection, so it
+		// is limited to the collections of the collstats collector.
+		var dataSizeNamespaces []string
+		if limitsOk {
+			dataSizeNamespaces = e.opts.CollStatsNamespaces
+		}
+		sc := newShardsCollector(ctx, client, e.opts.Logger, e.opts.CompatibleMode,
+			e.opts.ShardsChunkSizeSamples, e.opts.ShardsLargeUnshardedBytes,
+			e.opts.DiscoveringMode && limitsOk, dataSizeNamespaces)
+		registerer.MustRegister(sc)
+	}
+
+	if e.opts.EnableBalancer && nodeType == typeMongos && requestOpts.EnableBalancer {
+		bc := newBalancerCollector(ctx, client, e.opts.Logger, topologyInfo, e.balancer)
+		registerer.MustRegister(bc)
+	}
+
+	if e.opts.EnableDraining && nodeType == typeMongos && requestOpts.EnableDraining {
+		dc := newDrainingCollector(ctx, client, e.opts.Logger, topologyInfo, e.draining)
+		registerer.MustRegister(dc)
+	}
+
+	if e.opts.EnableResharding && nodeType == typeMongos && requestOpts.EnableResharding {
+		rc := newReshardingCollector(ctx, client, e.opts.Logger, topologyInfo)
+		registerer.MustRegister(rc)
+	}
+
+	clusterRole := topologyInfo.baseLabels()[labelClusterRole]
+
+	// config.mongos can be read through any mongos or config server.
+	isConfigServer := clusterRole == clusterRoleConfigServer
+	if e.opts.EnableMongosInventory && (nodeType == typeMongos || isConfigServer) && requestOpts.EnableMongosInventory {
+		mic := newMongosInventoryCollector(ctx, client, e.opts.Logger, topologyInfo, e.opts.MongosStaleThreshold)
+		registerer.MustRegister(mic)
+	}
+
+	// The range deletion tasks are on the shards, the orphaned documents are reported
+	// by mongos.
+	isShardServer := clusterRole == string(typeShardServer)
+	if e.opts.EnableRangeDeletions && (nodeType == typeMongos || isShardServer) && requestOpts.EnableRangeDeletions {
+		rdc := newRangeDeletionsCollector(ctx, client, e.opts.Logger, topologyInfo, nodeType)
+		registerer.MustRegister(rdc)
+	}
+
+	if e.opts.EnableConnPoolStats && requestOpts.EnableConnPoolStats {
+		cpc := newConnPoolStatsCollector(ctx, client, e.opts.Logger, topologyInfo)
+		registerer.MustRegister(cpc)
 	}
 
 	if e.opts.EnableFCV && nodeType != typeMongos {
 		fcvc := newFeatureCompatibilityCollector(ctx, client, e.opts.Logger)
-		registry.MustRegister(fcvc)
+		registerer.MustRegister(fcvc)
+	}
+
+	if e.opts.EnableOplog && nodeType != typeMongos && requestOpts.EnableOplog {
+		oc := newOplogCollector(ctx, client, e.opts.Logger, topologyInfo)
+		registerer.MustRegister(oc)
+	}
+
+	if e.opts.EnableOplogOps && nodeType != typeMongos && requestOpts.EnableOplogOps {
+		ooc := newOplogOpsCollector(ctx, client, e.opts.Logger, topologyInfo, e.oplogOps)
+		registerer.MustRegister(ooc)
 	}
 
 	if e.opts.EnablePBMMetrics && requestOpts.EnablePBMMetrics {
 		pbmc := newPbmCollector(ctx, client, e.opts.URI, e.opts.Logger)
-		registry.MustRegister(pbmc)
+		registerer.MustRegister(pbmc)
 	}
 
 	return registry
 }
 
+// externalLabels returns the static labels of the target merged with the labels
+// made from the replica set member tags. Static labels take precedence.
+func (e *Exporter) externalLabels(topologyInfo labelsGetter) prometheus.Labels {
+	labels := prometheus.Labels{}
+
+	if topologyInfo != nil && len(e.opts.MemberTagLabels) > 0 {
+		tags := topologyInfo.memberTags()
+		for _, tag := range e.opts.MemberTagLabels {
+			// Missing tags get an empty value to keep the same label set on all the members.
+			labels[tagLabelName(tag)] = tags[tag]
+		}
+	}
+
+	for k, v := range e.opts.ExternalLabels {
+		labels[k] = v
+	}
+
+	return labels
+}
+
+// tagLabelName converts a replica set member tag name to a valid label name.
+func tagLabelName(tag string) string {
+	return strings.TrimPrefix(prometheusize(tag), exporterPrefix)
+}
+
+// reservedLabelNames are the labels set by the exporter: the topology labels and the
+// labels of the collectors. External labels with the same name would make the
+// registry fail to gather.
+//
+//nolint:gochecknoglobals
+var reservedLabelNames = exporterLabelNames()
+
+// exporterLabelNames returns the labels set by the exporter. The labels made from
+// the keys of sub-documents and the labels of the v1 compatible metrics are taken
+// from their conversion tables, the others are listed here.
+func exporterLabelNames() map[string]bool {
+	names := map[string]bool{}
+	for _, name := range []string{
+		labelClusterRole, labelClusterID, labelReplicasetName, labelReplicasetState,
+		"instance", "job", "exporter", "collector",
+		"database", "db", "collection", "ns", "shard", "zone", "host", "pool", "name", "key",
+		"mongos", "member", "member_id", "member_idx", "member_state", "self", "set",
+		"role", "state", "status", "type", "mode", "reason", "result", "event", "version",
+		"mongodb", "edition", "vendor", "engine",
+		"op", "op_type", "opid", "desc", "operation", "side", "step",
+		"wait", "change", "from", "to", "sync_source", "tags",
+		"votes", "priority", "hidden", "arbiter", "delayed", "build_indexes",
+		"secondary_delay_secs", "shard_key", "hashed", "unique", "no_balance",
+		"uuid", "read_concern", "write_concern", "w", "lock_mode",
+		"resource", "key_name", "lower_bound",
+	} {
+		names[name] = true
+	}
+
+	for _, name := range nodeToPDMetrics {
+		names[name] = true
+	}
+	for _, name := range keyNodesToLabels {
+		names[name] = true
+	}
+	for _, c := range slices.Concat(conversions, specialConversions) {
+		for current, old := range c.labelConversions {
+			names[current], names[old] = true, true
+		}
+		if c.suffixLabel != "" {
+			names[c.suffixLabel] = true
+		}
+	}
+	for _, lm := range lockMetrics {
+		for name := range lm.labels {
+			names[name] = true
+		}
+	}
+
+	return names
+}
+
+// ValidateLabelNames returns an error if an external label name or the label name of
+// a member tag is set by the exporter, or if a member tag label has the name of an
+// external label.
+func ValidateLabelNames(externalLabels, memberTags []string) error {
+	names := make(map[string]string)
+	for _, name := range externalLabels {
+		if reservedLabelNames[name] {
+			return fmt.Errorf("external label %q is reserved by the exporter", name)
+		}
+		names[name] = "external label"
+	}
+
+	for _, tag := range memberTags {
+		name := tagLabelName(tag)
+		if reservedLabelNames[name] {
+			return fmt.Errorf("label %q of member tag %q is reserved by the exporter", name, tag)
+		}
+		if other, ok := names[name]; ok {
+			return fmt.Errorf("label %q of member tag %q collides with the %s of the same name", name, tag, other)
+		}
+		names[name] = fmt.Sprintf("member tag %q", tag)
+	}
+
+	return nil
+}
+
 func (e *Exporter) getClient(ctx context.Context) (*mongo.Client, error) {
+	// A closed exporter must not connect again, nothing would disconnect it.
+	e.clientMu.Lock()
+	closed := e.closed
+	e.clientMu.Unlock()
+	if closed {
+		return nil, errExporterClosed
+	}
+
 	if e.opts.GlobalConnPool {
 		// Get global client. Maybe it must be initialized first.
 		// Initialization is retried with every scrape until it succeeds once.
 		e.clientMu.Lock()
 		defer e.clientMu.Unlock()
 
+		if e.closed {
+			return nil, errExporterClosed
+		}
+
 		// If client is already initialized, and Ping is successful -- return it.
 		if e.client != nil {
 			err := e.client.Ping(ctx, nil)
@@ -284,7 +565,7 @@ func (e *Exporter) getClient(ctx context.Context) (*mongo.Client, error) {
 			return e.client, nil
 		}
 
-		client, err := connect(context.Background(), e.opts)
+		client, err := connectWithMonitor(context.Background(), e.opts, e.topology.serverMonitor())
 		if err != nil {
 			return nil, err
 		}
@@ -294,7 +575,7 @@ func (e *Exporter) getClient(ctx context.Context) (*mongo.Client, error) {
 	}
 
 	// !e.opts.GlobalConnPool: create new client for every scrape.
-	client, err := connect(ctx, e.opts)
+	client, err := connectWithMonitor(ctx, e.opts, e.topology.serverMonitor())
 	if err != nil {
 		return nil, err
 	}
@@ -302,10 +583,75 @@ func (e *Exporter) getClient(ctx context.Context) (*mongo.Client, error) {
 	return client, nil
 }
 
+// Close stops the canary probe and disconnects the global connection pool, if any.
+// The exporter must not be used after calling Close.
+func (e *Exporter) Close(ctx context.Context) error {
+	if e.canary != nil {
+		e.canary.stop(ctx)
+	}
+
+	e.clientMu.Lock()
+	defer e.clientMu.Unlock()
+
+	e.closed = true
+	if e.client == nil {
+		return nil
+	}
+
+	err := e.client.Disconnect(ctx)
+	e.client = nil
+
+	return err
+}
+
+// beginScrape records a request being served by the exporter.
+func (e *Exporter) beginScrape() {
+	e.scrapesMu.Lock()
+	defer e.scrapesMu.Unlock()
+
+	e.scrapes++
+}
+
+// endScrape records the end of a request and wakes up waitIdle once none is left.
+func (e *Exporter) endScrape() {
+	e.scrapesMu.Lock()
+	defer e.scrapesMu.Unlock()
+
+	e.scrapes--
+	if e.scrapes == 0 && e.idle != nil {
+		close(e.idle)
+		e.idle = nil
+	}
+}
+
+// waitIdle waits until no request is being served by the exporter.
+func (e *Exporter) waitIdle(ctx context.Context) error {
+	e.scrapesMu.Lock()
+	if e.scrapes == 0 {
+		e.scrapesMu.Unlock()
+		return nil
+	}
+	if e.idle == nil {
+		e.idle = make(chan struct{})
+	}
+	idle := e.idle
+	e.scrapesMu.Unlock()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 // Handler returns an http.Handler that serves metrics. Can be used instead of
 // run for hooking up custom HTTP servers.
 func (e *Exporter) Handler() http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		e.beginScrape()
+		defer e.endScrape()
+
 		scrapeTimeoutHeader := r.Header.Get("X-Prometheus-Scrape-Timeout-Seconds")
 		seconds := 10.0
 
@@ -359,13 +705,14 @@ func (e *Exporter) Handler() http.Handler {
 		var registry *prometheus.Registry
 		var ti *topologyInfo
 		if client != nil {
-			// Topology can change between requests, so we need to get it every time.
-			ti = newTopologyInfo(ctx, client, e.logger)
+			// Topology can change between requests. It is cached for a short time and
+			// reloaded earlier when the driver reports a state change.
+			ti = e.topology.get(ctx, client)
 			registry = e.makeRegistry(ctx, client, ti, requestOpts)
 		} else {
 			registry = prometheus.NewRegistry()
 			gc := newGeneralCollector(ctx, client, "", e.opts.Logger)
-			registry.MustRegister(gc)
+			prometheus.WrapRegistererWith(e.externalLabels(nil), registry).MustRegister(gc)
 		}
 
 		gatherers = append(gatherers, registry)
@@ -414,6 +761,28 @@ func GetRequestOpts(filters []string, defaultOpts *Opts) Opts {
 			requestOpts.EnableFCV = true
 		case "pbm":
 			requestOpts.EnablePBMMetrics = true
+		case "oplog":
+			requestOpts.EnableOplog = true
+		case "replication":
+			requestOpts.EnableReplication = true
+		case "replicationevents":
+			requestOpts.EnableReplicationEvents = true
+		case "canary":
+			requestOpts.EnableCanary = true
+		case "oplogops":
+			requestOpts.EnableOplogOps = true
+		case "balancer":
+			requestOpts.EnableBalancer = true
+		case "mongosinventory":
+			requestOpts.EnableMongosInventory = true
+		case "draining":
+			requestOpts.EnableDraining = true
+		case "rangedeletions":
+			requestOpts.EnableRangeDeletions = true
+		case "resharding":
+			requestOpts.EnableResharding = true
+		case "connpoolstats":
+			requestOpts.EnableConnPoolStats = true
 		}
 	}
 
@@ -421,6 +790,12 @@ func GetRequestOpts(filters []string, defaultOpts *Opts) Opts {
 }
 
 func connect(ctx context.Context, opts *Opts) (*mongo.Client, error) {
+	return connectWithMonitor(ctx, opts, nil)
+}
+
+// connectWithMonitor connects like connect, reporting the server monitoring events
+// to monitor if it is not nil.
+func connectWithMonitor(ctx context.Context, opts *Opts, monitor *event.ServerMonitor) (*mongo.Client, error) {
 	clientOpts, err := dsn_fix.ClientOptionsForDSN(opts.URI)
 	if err != nil {
 		return nil, fmt.Errorf("invalid dsn: %w", err)
@@ -428,6 +803,9 @@ func connect(ctx context.Context, opts *Opts) (*mongo.Client, error) {
 
 	clientOpts.SetDirect(opts.DirectConnect)
 	clientOpts.SetAppName("mongodb_exporter")
+	if monitor != nil {
+		clientOpts.SetServerMonitor(monitor)
+	}
 
 	if clientOpts.ConnectTimeout == nil {
 		connectTimeout := time.Duration(opts.ConnectTimeoutMS) * time.Millisecond
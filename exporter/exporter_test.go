@@ -57,6 +57,14 @@ func (l labelsGetterMock) baseLabels() map[string]string {
 	return map[string]string{}
 }
 
+func (l labelsGetterMock) memberTags() map[string]string {
+	return map[string]string{}
+}
+
+func (l labelsGetterMock) nodeType() mongoDBNodeType {
+	return typeOther
+}
+
 func (l labelsGetterMock) loadLabels(context.Context) error {
 	return nil
 }
@@ -71,6 +79,20 @@ func metricLabels(m *dto.Metric) map[string]string {
 	return labels
 }
 
+// metricsCollector is a prometheus.Collector sending a fixed list of metrics, to
+// compare the metrics built by a function with testutil.CollectAndCompare.
+type metricsCollector []prometheus.Metric
+
+func (c metricsCollector) Describe(ch chan<- *prometheus.Desc) {
+	prometheus.DescribeByCollect(c, ch)
+}
+
+func (c metricsCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, m := range c {
+		ch <- m
+	}
+}
+
 // shardTestCollection shards dbName.collName over every shard of the test cluster
 // reached through mongos, so that $collStats and $indexStats report one document
 // per shard. It skips the test only when the cluster itself cannot exercise
@@ -451,3 +473,88 @@ func TestMongoUpMetric(t *testing.T) {
 		})
 	}
 }
+
+type memberTagsMock struct {
+	labelsGetterMock
+	tags map[string]string
+}
+
+func (m memberTagsMock) memberTags() map[string]string {
+	return m.tags
+}
+
+func TestExternalLabels(t *testing.T) {
+	t.Parallel()
+
+	e := &Exporter{opts: &Opts{
+		ExternalLabels:  map[string]string{"env": "prod", "dc": "static"},
+		MemberTagLabels: []string{"dc", "rack", "zone-name"},
+	}}
+
+	ti := memberTagsMock{tags: map[string]string{"dc": "east", "rack": "r1", "other": "x"}}
+	want := prometheus.Labels{"env": "prod", "dc": "static", "rack": "r1", "zone_name": ""}
+	assert.Equal(t, want, e.externalLabels(ti))
+
+	want = prometheus.Labels{"env": "prod", "dc": "static"}
+	assert.Equal(t, want, e.externalLabels(nil))
+}
+
+func TestValidateLabelNames(t *testing.T) {
+	t.Parallel()
+
+	assert.NoError(t, ValidateLabelNames([]string{"env", "team"}, []string{"dc", "rack", "zone-name"}))
+
+	// Labels set by the exporter, the zone tag clashes with the zone metrics.
+	assert.Error(t, ValidateLabelNames([]string{"rs_nm"}, nil))
+	assert.Error(t, ValidateLabelNames([]string{"shard"}, nil))
+	assert.Error(t, ValidateLabelNames(nil, []string{"zone"}))
+	assert.Error(t, ValidateLabelNames(nil, []string{"cl-role"}))
+
+	// A member tag label cannot replace an external label or another tag label.
+	assert.Error(t, ValidateLabelNames([]string{"dc"}, []string{"dc"}))
+	assert.Error(t, ValidateLabelNames(nil, []string{"zone-name", "zone_name"}))
+
+	// Labels of the serverStatus and v1 compatible metrics.
+	for _, name := range []string{"conn_type", "csr_type", "count_type", "assert_type", "index_name", "legacy_op_type", "doc_op_type", "txn_rw_type", "cl_role", "edition", "vendor", "key"} {
+		assert.Error(t, ValidateLabelNames([]string{name}, nil), name)
+	}
+
+	// Every label of the metrics made from a serverStatus document is reserved.
+	serverStatus := bson.M{"serverStatus": bson.M{
+		"connections":  bson.M{"current": int32(1)},
+		"asserts":      bson.M{"regular": int32(1)},
+		"opcounters":   bson.M{"insert": int32(1)},
+		"metrics":      bson.M{"cursor": bson.M{"open": bson.M{"total": int32(1)}}, "document": bson.M{"inserted": int32(1)}},
+		"globalLock":   bson.M{"currentQueue": bson.M{"readers": int32(1)}},
+		"opLatencies":  bson.M{"reads": bson.M{"ops": int32(1)}},
+		"transactions": bson.M{"commitTypes": bson.M{"noShards": bson.M{"initiated": int32(1)}}},
+		"wiredTiger":   bson.M{"concurrentTransactions": bson.M{"read": bson.M{"out": int32(1)}}},
+	}}
+	metrics := makeMetrics("", serverStatus, nil, true)
+	require.NotEmpty(t, metrics)
+	for _, m := range metrics {
+		var pb dto.Metric
+		require.NoError(t, m.Write(&pb))
+		for _, l := range pb.GetLabel() {
+			assert.Error(t, ValidateLabelNames([]string{l.GetName()}, nil), "%s of %s", l.GetName(), m.Desc())
+		}
+	}
+}
+
+func TestExternalLabelsWithoutConnection(t *testing.T) {
+	t.Parallel()
+
+	e := New(&Opts{
+		URI:                    "mongodb://127.0.0.1:1",
+		DirectConnect:          true,
+		ConnectTimeoutMS:       100,
+		DisableDefaultRegistry: true,
+		ExternalLabels:         map[string]string{"env": "prod"},
+	})
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	e.Handler().ServeHTTP(rr, req)
+
+	assert.Contains(t, rr.Body.String(), `mongodb_up{cluster_role="",env="prod"} 0`)
+}
@@ -0,0 +1,154 @@
+// mongodb_exporter
+// Copyright (C) 2017 Percona LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	// DefaultMongosStaleThreshold is the default ping age after which a mongos is
+	// reported as stale. A running mongos pings the config servers every 30 seconds.
+	DefaultMongosStaleThreshold = 2 * time.Minute
+
+	clusterRoleConfigServer = "configsvr"
+)
+
+// mongosPing is a document of config.mongos, written by each mongos of the cluster.
+type mongosPing struct {
+	ID           string    `bson:"_id"`
+	Ping         time.Time `bson:"ping"`
+	Up           int64     `bson:"up"`
+	Waiting      bool      `bson:"waiting"`
+	MongoVersion string    `bson:"mongoVersion"`
+}
+
+type mongosInventoryCollector struct {
+	ctx  context.Context
+	base *baseCollector
+
+	topologyInfo   labelsGetter
+	staleThreshold time.Duration
+}
+
+// newMongosInventoryCollector creates a collector for the routers of a sharded
+// cluster, as registered in config.mongos.
+func newMongosInventoryCollector(ctx context.Context, client *mongo.Client, logger *slog.Logger, topology labelsGetter, staleThreshold time.Duration) *mongosInventoryCollector {
+	if staleThreshold <= 0 {
+		staleThreshold = DefaultMongosStaleThreshold
+	}
+
+	return &mongosInventoryCollector{
+		ctx:  ctx,
+		base: newBaseCollector(client, logger.With("collector", "mongos_inventory")),
+
+		topologyInfo:   topology,
+		staleThreshold: staleThreshold,
+	}
+}
+
+func (d *mongosInventoryCollector) Describe(ch chan<- *prometheus.Desc) {
+	d.base.Describe(d.ctx, ch, d.collect)
+}
+
+func (d *mongosInventoryCollector) Collect(ch chan<- prometheus.Metric) {
+	d.base.Collect(ch)
+}
+
+func (d *mongosInventoryCollector) collect(ch chan<- prometheus.Metric) {
+	defer measureCollectTime(ch, "mongodb", "mongos_inventory")()
+
+	logger := d.base.logger
+	client := d.base.client
+	if client == nil {
+		return
+	}
+
+	cursor, err := client.Database("config").Collection("mongos").Find(d.ctx, bson.M{})
+	if err != nil {
+		logger.Error("cannot read config.mongos", "error", err)
+		return
+	}
+
+	var routers []mongosPing
+	if err := cursor.All(d.ctx, &routers); err != nil {
+		logger.Error("cannot decode config.mongos", "error", err)
+		return
+	}
+
+	for _, metric := range mongosInventoryMetrics(routers, time.Now(), d.staleThreshold, d.topologyInfo.baseLabels()) {
+		ch <- metric
+	}
+}
+
+// mongosInventoryMetrics makes the metrics of each router and the number of routers
+// which did not ping the config servers for longer than staleThreshold.
+func mongosInventoryMetrics(routers []mongosPing, now time.Time, staleThreshold time.Duration, labels map[string]string) []prometheus.Metric {
+	pingAgeDesc := prometheus.NewDesc("mongodb_mongos_ping_age_seconds",
+		"Time since the mongos last pinged the config servers", []string{"mongos"}, labels)
+	uptimeDesc := prometheus.NewDesc("mongodb_mongos_uptime_seconds",
+		"Uptime of the mongos as of its last ping", []string{"mongos"}, labels)
+	waitingDesc := prometheus.NewDesc("mongodb_mongos_waiting",
+		"1 if the mongos is waiting for work (waiting field of config.mongos)", []string{"mongos"}, labels)
+	staleDesc := prometheus.NewDesc("mongodb_mongos_stale",
+		"1 if the last ping of the mongos is older than the stale threshold", []string{"mongos"}, labels)
+	versionDesc := prometheus.NewDesc("mongodb_mongos_version_info",
+		"Version of the mongos as of its last ping", []string{"mongos", "version"}, labels)
+
+	metrics := make([]prometheus.Metric, 0, 5*len(routers)+2) //nolint:mnd
+	stale := 0
+
+	for _, r := range routers {
+		age := now.Sub(r.Ping)
+
+		isStale := 0.0
+		if age > staleThreshold {
+			isStale = 1
+			stale++
+		}
+
+		waiting := 0.0
+		if r.Waiting {
+			waiting = 1
+		}
+
+		metrics = append(metrics,
+			prometheus.MustNewConstMetric(pingAgeDesc, prometheus.GaugeValue, age.Seconds(), r.ID),
+			prometheus.MustNewConstMetric(uptimeDesc, prometheus.GaugeValue, float64(r.Up), r.ID),
+			prometheus.MustNewConstMetric(waitingDesc, prometheus.GaugeValue, waiting, r.ID),
+			prometheus.MustNewConstMetric(staleDesc, prometheus.GaugeValue, isStale, r.ID),
+			prometheus.MustNewConstMetric(versionDesc, prometheus.GaugeValue, 1, r.ID, r.MongoVersion),
+		)
+	}
+
+	routersDesc := prometheus.NewDesc("mongodb_mongos_routers",
+		"Number of mongos registered in config.mongos", nil, labels)
+	staleRoutersDesc := prometheus.NewDesc("mongodb_mongos_stale_routers",
+		"Number of mongos registered in config.mongos whose last ping is older than the stale threshold", nil, labels)
+
+	return append(metrics,
+		prometheus.MustNewConstMetric(routersDesc, prometheus.GaugeValue, float64(len(routers))),
+		prometheus.MustNewConstMetric(staleRoutersDesc, prometheus.GaugeValue, float64(stale)),
+	)
+}
+
+var _ prometheus.Collector = (*mongosInventoryCollector)(nil)
@@ -0,0 +1,85 @@
+// mongodb_exporter
+// Copyright (C) 2017 Percona LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/promslog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/percona/mongodb_exporter/internal/tu"
+)
+
+func TestMongosInventoryCollector(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	client := tu.DefaultTestClientMongoS(ctx, t)
+
+	c := newMongosInventoryCollector(ctx, client, promslog.New(&promslog.Config{}), labelsGetterMock{}, 0)
+
+	expected := strings.NewReader(`
+	# HELP mongodb_mongos_stale_routers Number of mongos registered in config.mongos whose last ping is older than the stale threshold
+	# TYPE mongodb_mongos_stale_routers gauge
+	mongodb_mongos_stale_routers 0` + "\n")
+	assert.NoError(t, testutil.CollectAndCompare(c, expected, "mongodb_mongos_stale_routers"))
+	assert.Equal(t, 1, testutil.CollectAndCount(c, "mongodb_mongos_routers"))
+}
+
+func TestMongosInventoryMetrics(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	routers := []mongosPing{
+		{ID: "mongos1:27017", Ping: now.Add(-10 * time.Second), Up: 3600, Waiting: true, MongoVersion: "7.0.14"},
+		{ID: "mongos2:27017", Ping: now.Add(-time.Hour), Up: 60, MongoVersion: "6.0.18"},
+	}
+
+	expected := strings.NewReader(`
+	# HELP mongodb_mongos_ping_age_seconds Time since the mongos last pinged the config servers
+	# TYPE mongodb_mongos_ping_age_seconds gauge
+	mongodb_mongos_ping_age_seconds{mongos="mongos1:27017"} 10
+	mongodb_mongos_ping_age_seconds{mongos="mongos2:27017"} 3600
+	# HELP mongodb_mongos_routers Number of mongos registered in config.mongos
+	# TYPE mongodb_mongos_routers gauge
+	mongodb_mongos_routers 2
+	# HELP mongodb_mongos_stale 1 if the last ping of the mongos is older than the stale threshold
+	# TYPE mongodb_mongos_stale gauge
+	mongodb_mongos_stale{mongos="mongos1:27017"} 0
+	mongodb_mongos_stale{mongos="mongos2:27017"} 1
+	# HELP mongodb_mongos_stale_routers Number of mongos registered in config.mongos whose last ping is older than the stale threshold
+	# TYPE mongodb_mongos_stale_routers gauge
+	mongodb_mongos_stale_routers 1
+	# HELP mongodb_mongos_uptime_seconds Uptime of the mongos as of its last ping
+	# TYPE mongodb_mongos_uptime_seconds gauge
+	mongodb_mongos_uptime_seconds{mongos="mongos1:27017"} 3600
+	mongodb_mongos_uptime_seconds{mongos="mongos2:27017"} 60
+	# HELP mongodb_mongos_version_info Version of the mongos as of its last ping
+	# TYPE mongodb_mongos_version_info gauge
+	mongodb_mongos_version_info{mongos="mongos1:27017",version="7.0.14"} 1
+	mongodb_mongos_version_info{mongos="mongos2:27017",version="6.0.18"} 1
+	# HELP mongodb_mongos_waiting 1 if the mongos is waiting for work (waiting field of config.mongos)
+	# TYPE mongodb_mongos_waiting gauge
+	mongodb_mongos_waiting{mongos="mongos1:27017"} 1
+	mongodb_mongos_waiting{mongos="mongos2:27017"} 0` + "\n")
+	require.NoError(t, testutil.CollectAndCompare(metricsCollector(mongosInventoryMetrics(routers, now, DefaultMongosStaleThreshold, nil)), expected))
+}
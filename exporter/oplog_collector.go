@@ -0,0 +1,238 @@
+// mongodb_exporter
+// Copyright (C) 2017 Percona LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Number of newest oplog entries used to measure the recent write rate.
+const oplogRecentEntries = 1000
+
+type oplogCollector struct {
+	ctx  context.Context
+	base *baseCollector
+
+	topologyInfo labelsGetter
+}
+
+// oplogStats holds the storage stats of the oplog collection.
+type oplogStats struct {
+	Size       float64 `bson:"size"`
+	Count      float64 `bson:"count"`
+	MaxSize    float64 `bson:"maxSize"`
+	AvgObjSize float64 `bson:"avgObjSize"`
+}
+
+// oplogWindow holds the oplog window and the rates derived from it.
+type oplogWindow struct {
+	first primitive.Timestamp
+	last  primitive.Timestamp
+	stats oplogStats
+
+	// Oldest of the newest entries and how many entries were read.
+	recentOldest primitive.Timestamp
+	recentCount  int
+}
+
+// newOplogCollector creates a collector for the oplog window and growth rate.
+func newOplogCollector(ctx context.Context, client *mongo.Client, logger *slog.Logger, topology labelsGetter) *oplogCollector {
+	return &oplogCollector{
+		ctx:  ctx,
+		base: newBaseCollector(client, logger.With("collector", "oplog")),
+
+		topologyInfo: topology,
+	}
+}
+
+func (d *oplogCollector) Describe(ch chan<- *prometheus.Desc) {
+	d.base.Describe(d.ctx, ch, d.collect)
+}
+
+func (d *oplogCollector) Collect(ch chan<- prometheus.Metric) {
+	d.base.Collect(ch)
+}
+
+func (d *oplogCollector) collect(ch chan<- prometheus.Metric) {
+	defer measureCollectTime(ch, "mongodb", "oplog")()
+
+	logger := d.base.logger
+	client := d.base.client
+	if client == nil {
+		return
+	}
+
+	window, err := getOplogWindow(d.ctx, client)
+	if err != nil {
+		// Standalone instances and mongos don't have an oplog.
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			logger.Debug("oplog is empty or does not exist")
+			return
+		}
+		logger.Error("cannot get oplog window", "error", err)
+		ch <- prometheus.NewInvalidMetric(prometheus.NewInvalidDesc(err), err)
+
+		return
+	}
+
+	for _, metric := range window.metrics(d.topologyInfo.baseLabels()) {
+		ch <- metric
+	}
+}
+
+func getOplogWindow(ctx context.Context, client *mongo.Client) (*oplogWindow, error) {
+	oplog := client.Database("local").Collection("oplog.rs")
+	window := &oplogWindow{}
+
+	var err error
+	if window.first, err = oplogEntryTimestamp(ctx, oplog, 1); err != nil {
+		return nil, err
+	}
+	if window.last, err = oplogEntryTimestamp(ctx, oplog, -1); err != nil {
+		return nil, err
+	}
+
+	cursor, err := oplog.Aggregate(ctx, bson.A{bson.M{"$collStats": bson.M{"storageStats": bson.M{}}}})
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot get oplog storage stats")
+	}
+
+	var stats []struct {
+		StorageStats oplogStats `bson:"storageStats"`
+	}
+	if err := cursor.All(ctx, &stats); err != nil {
+		return nil, errors.Wrap(err, "cannot decode oplog storage stats")
+	}
+	if len(stats) > 0 {
+		window.stats = stats[0].StorageStats
+	}
+
+	findOpts := options.Find().
+		SetSort(bson.M{"$natural": -1}).
+		SetLimit(oplogRecentEntries).
+		SetProjection(bson.M{"ts": 1, "_id": 0})
+
+	cursor, err = oplog.Find(ctx, bson.M{}, findOpts)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot read the newest oplog entries")
+	}
+	defer cursor.Close(ctx) //nolint:errcheck
+
+	for cursor.Next(ctx) {
+		var entry struct {
+			Timestamp primitive.Timestamp `bson:"ts"`
+		}
+		if err := cursor.Decode(&entry); err != nil {
+			return nil, errors.Wrap(err, "cannot decode oplog entry")
+		}
+		window.recentOldest = entry.Timestamp
+		window.recentCount++
+	}
+
+	return window, errors.Wrap(cursor.Err(), "cannot read the newest oplog entries")
+}
+
+// oplogEntryTimestamp returns the timestamp of the oldest (order 1) or the newest
+// (order -1) oplog entry.
+func oplogEntryTimestamp(ctx context.Context, oplog *mongo.Collection, order int) (primitive.Timestamp, error) {
+	var entry struct {
+		Timestamp primitive.Timestamp `bson:"ts"`
+	}
+
+	opts := options.FindOne().SetSort(bson.M{"$natural": order}).SetProjection(bson.M{"ts": 1, "_id": 0})
+	if err := oplog.FindOne(ctx, bson.M{}, opts).Decode(&entry); err != nil {
+		return primitive.Timestamp{}, err
+	}
+
+	return entry.Timestamp, nil
+}
+
+// windowSeconds returns the time covered by the oplog.
+func (s *oplogWindow) windowSeconds() float64 {
+	return float64(s.last.T) - float64(s.first.T)
+}
+
+// entriesRate returns the number of entries written per second, measured on the
+// newest entries. It is zero if the rate cannot be measured.
+func (s *oplogWindow) entriesRate() float64 {
+	if s.recentCount < 2 { //nolint:mnd
+		return 0
+	}
+
+	// Entries written in the same second are counted over one second.
+	elapsed := max(float64(s.last.T)-float64(s.recentOldest.T), 1)
+
+	// The oldest entry only marks the start of the interval.
+	return float64(s.recentCount-1) / elapsed
+}
+
+// bytesRate estimates the bytes written to the oplog per second using the
+// average entry size.
+func (s *oplogWindow) bytesRate() float64 {
+	return s.entriesRate() * s.stats.AvgObjSize
+}
+
+// projectedWindowSeconds returns how long the oplog will cover once full at the
+// recent write rate: the current window plus the time to fill the free space. A
+// full oplog keeps its current window. It is zero if the rate is unknown.
+func (s *oplogWindow) projectedWindowSeconds() float64 {
+	rate := s.bytesRate()
+	if rate == 0 || s.stats.MaxSize == 0 {
+		return 0
+	}
+
+	return s.windowSeconds() + max(s.stats.MaxSize-s.stats.Size, 0)/rate
+}
+
+func (s *oplogWindow) metrics(labels map[string]string) []prometheus.Metric {
+	gauge := func(name, help string, value float64) prometheus.Metric {
+		desc := prometheus.NewDesc("mongodb_oplog_"+name, help, nil, labels)
+		return prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, value)
+	}
+
+	metrics := []prometheus.Metric{
+		gauge("first_timestamp_seconds", "Timestamp of the oldest entry in the oplog", float64(s.first.T)),
+		gauge("last_timestamp_seconds", "Timestamp of the newest entry in the oplog", float64(s.last.T)),
+		gauge("window_seconds", "Time covered by the oplog, between the oldest and the newest entries", s.windowSeconds()),
+		gauge("max_size_bytes", "Configured maximum size of the oplog", s.stats.MaxSize),
+		gauge("size_bytes", "Size of the entries in the oplog", s.stats.Size),
+		gauge("entries", "Number of entries in the oplog", s.stats.Count),
+	}
+
+	if s.entriesRate() > 0 {
+		metrics = append(metrics,
+			gauge("recent_entries_per_second", "Entries written per second, measured on the newest entries", s.entriesRate()),
+			gauge("recent_bytes_per_second", "Estimated bytes written per second, measured on the newest entries", s.bytesRate()),
+		)
+	}
+
+	if projected := s.projectedWindowSeconds(); projected > 0 {
+		metrics = append(metrics, gauge("projected_window_seconds",
+			"Time the oplog will cover once full at the recent write rate, the current window plus the time to fill the free space", projected))
+	}
+
+	return metrics
+}
+
+var _ prometheus.Collector = (*oplogCollector)(nil)
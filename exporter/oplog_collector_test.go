@@ -0,0 +1,82 @@
+// mongodb_exporter
+// Copyright (C) 2017 Percona LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/promslog"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/percona/mongodb_exporter/internal/tu"
+)
+
+func TestOplogCollector(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	client := tu.DefaultTestClient(ctx, t)
+
+	c := newOplogCollector(ctx, client, promslog.New(&promslog.Config{}), labelsGetterMock{})
+
+	// Values depend on the activity of the test cluster, only check the metrics exist.
+	for _, name := range []string{
+		"mongodb_oplog_first_timestamp_seconds",
+		"mongodb_oplog_last_timestamp_seconds",
+		"mongodb_oplog_window_seconds",
+		"mongodb_oplog_max_size_bytes",
+		"mongodb_oplog_size_bytes",
+	} {
+		assert.Equal(t, 1, testutil.CollectAndCount(c, name), name)
+	}
+}
+
+func TestOplogWindow(t *testing.T) {
+	t.Parallel()
+
+	s := &oplogWindow{
+		first: primitive.Timestamp{T: 1000},
+		last:  primitive.Timestamp{T: 4600, I: 3},
+		stats: oplogStats{Size: 1 << 20, Count: 2000, MaxSize: 10 << 20, AvgObjSize: 512},
+
+		recentOldest: primitive.Timestamp{T: 4500},
+		recentCount:  1001,
+	}
+
+	assert.InDelta(t, 3600, s.windowSeconds(), 0)
+	assert.InDelta(t, 10, s.entriesRate(), 0)
+	assert.InDelta(t, 5120, s.bytesRate(), 0)
+	// The 9MiB left take 1843.2s to fill at 5120 bytes per second.
+	assert.InDelta(t, 5443.2, s.projectedWindowSeconds(), 1e-9)
+
+	// A full oplog keeps its window.
+	s.stats.Size = s.stats.MaxSize
+	assert.InDelta(t, 3600, s.projectedWindowSeconds(), 0)
+
+	// All the newest entries written in the same second.
+	s.recentOldest = s.last
+	assert.InDelta(t, 1000, s.entriesRate(), 0)
+
+	// A single entry is not enough to measure a rate.
+	s.recentCount = 1
+	assert.Zero(t, s.entriesRate())
+	assert.Zero(t, s.projectedWindowSeconds())
+	assert.Len(t, s.metrics(nil), 6)
+}
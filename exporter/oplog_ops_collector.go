@@ -0,0 +1,232 @@
+// mongodb_exporter
+// Copyright (C) 2017 Percona LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// DefaultOplogOpsMaxNamespaces is the default number of namespaces tracked by
+	// the oplog ops collector.
+	DefaultOplogOpsMaxNamespaces = 100
+
+	// Namespace of the entries of the namespaces over the limit.
+	oplogOpsOtherNamespace = "_other"
+	// Most entries read by a scrape, the next scrape goes on from there.
+	oplogOpsMaxEntries = 100000
+	// Error code returned when the position of a tailable cursor was overwritten.
+	cappedPositionLost = 136
+)
+
+type oplogOpKey struct {
+	ns string
+	op string
+}
+
+// oplogOpsTracker keeps the position in the oplog and the counters of the entries
+// read since the exporter started.
+type oplogOpsTracker struct {
+	maxNamespaces int
+
+	mu         sync.Mutex
+	last       primitive.Timestamp
+	namespaces map[string]bool
+	entries    map[oplogOpKey]float64
+	bytes      map[oplogOpKey]float64
+}
+
+func newOplogOpsTracker(maxNamespaces int) *oplogOpsTracker {
+	if maxNamespaces <= 0 {
+		maxNamespaces = DefaultOplogOpsMaxNamespaces
+	}
+
+	return &oplogOpsTracker{
+		maxNamespaces: maxNamespaces,
+		namespaces:    make(map[string]bool),
+		entries:       make(map[oplogOpKey]float64),
+		bytes:         make(map[oplogOpKey]float64),
+	}
+}
+
+// add counts an oplog entry. Once the namespaces limit is reached, the entries of
+// new namespaces are counted under a single namespace. It must be called with mu held.
+func (t *oplogOpsTracker) add(ns, op string, size int) {
+	if !t.namespaces[ns] {
+		if len(t.namespaces) >= t.maxNamespaces {
+			ns = oplogOpsOtherNamespace
+		} else {
+			t.namespaces[ns] = true
+		}
+	}
+
+	key := oplogOpKey{ns: ns, op: op}
+	t.entries[key]++
+	t.bytes[key] += float64(size)
+}
+
+// read counts the oplog entries written since the last read. The first read only
+// records the position of the newest entry.
+func (t *oplogOpsTracker) read(ctx context.Context, client *mongo.Client) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	oplog := client.Database("local").Collection("oplog.rs")
+
+	if t.last.IsZero() {
+		last, err := oplogEntryTimestamp(ctx, oplog, -1)
+		if err != nil {
+			return err
+		}
+		t.last = last
+
+		return nil
+	}
+
+	opts := options.Find().SetCursorType(options.Tailable)
+
+	cursor, err := oplog.Find(ctx, bson.D{{Key: "ts", Value: bson.D{{Key: "$gt", Value: t.last}}}}, opts)
+	if err != nil {
+		return t.checkPosition(ctx, oplog, err)
+	}
+	defer cursor.Close(ctx) //nolint:errcheck
+
+	// TryNext doesn't wait for new entries once the tail is reached.
+	for n := 0; n < oplogOpsMaxEntries && cursor.TryNext(ctx); n++ {
+		raw := cursor.Current
+
+		sec, inc, ok := raw.Lookup("ts").TimestampOK()
+		if !ok {
+			continue
+		}
+		ns, _ := raw.Lookup("ns").StringValueOK()
+		op, _ := raw.Lookup("op").StringValueOK()
+
+		t.add(ns, op, len(raw))
+		t.last = primitive.Timestamp{T: sec, I: inc}
+	}
+
+	if err := cursor.Err(); err != nil {
+		return t.checkPosition(ctx, oplog, err)
+	}
+
+	return nil
+}
+
+// checkPosition restarts from the newest entry if the last read position is not
+// in the oplog anymore, since the entries in between are lost.
+func (t *oplogOpsTracker) checkPosition(ctx context.Context, oplog *mongo.Collection, err error) error {
+	var e mongo.ServerError
+	if !errors.As(err, &e) || !e.HasErrorCode(cappedPositionLost) {
+		return err
+	}
+
+	last, lerr := oplogEntryTimestamp(ctx, oplog, -1)
+	if lerr != nil {
+		return lerr
+	}
+	t.last = last
+
+	return fmt.Errorf("oplog position lost, restarting from the newest entry: %w", err)
+}
+
+// metrics makes the counters of the entries read from the oplog.
+func (t *oplogOpsTracker) metrics(labels map[string]string) []prometheus.Metric {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	entriesDesc := prometheus.NewDesc("mongodb_oplog_ops_total",
+		"Number of oplog entries read since the exporter started, by namespace and operation", []string{"ns", "op"}, labels)
+	bytesDesc := prometheus.NewDesc("mongodb_oplog_ops_bytes_total",
+		"Approximate size of the oplog entries read since the exporter started, by namespace and operation", []string{"ns", "op"}, labels)
+
+	metrics := make([]prometheus.Metric, 0, 2*len(t.entries)+1) //nolint:mnd
+	for key, n := range t.entries {
+		metrics = append(metrics,
+			prometheus.MustNewConstMetric(entriesDesc, prometheus.CounterValue, n, key.ns, key.op),
+			prometheus.MustNewConstMetric(bytesDesc, prometheus.CounterValue, t.bytes[key], key.ns, key.op),
+		)
+	}
+
+	positionDesc := prometheus.NewDesc("mongodb_oplog_ops_last_timestamp_seconds",
+		"Timestamp of the last oplog entry read by the oplog ops collector", nil, labels)
+	metrics = append(metrics, prometheus.MustNewConstMetric(positionDesc, prometheus.GaugeValue, float64(t.last.T)))
+
+	return metrics
+}
+
+type oplogOpsCollector struct {
+	ctx  context.Context
+	base *baseCollector
+
+	topologyInfo labelsGetter
+	tracker      *oplogOpsTracker
+}
+
+// newOplogOpsCollector creates a collector counting the oplog entries by namespace
+// and operation.
+func newOplogOpsCollector(ctx context.Context, client *mongo.Client, logger *slog.Logger, topology labelsGetter, tracker *oplogOpsTracker) *oplogOpsCollector {
+	return &oplogOpsCollector{
+		ctx:  ctx,
+		base: newBaseCollector(client, logger.With("collector", "oplog_ops")),
+
+		topologyInfo: topology,
+		tracker:      tracker,
+	}
+}
+
+func (d *oplogOpsCollector) Describe(ch chan<- *prometheus.Desc) {
+	d.base.Describe(d.ctx, ch, d.collect)
+}
+
+func (d *oplogOpsCollector) Collect(ch chan<- prometheus.Metric) {
+	d.base.Collect(ch)
+}
+
+func (d *oplogOpsCollector) collect(ch chan<- prometheus.Metric) {
+	defer measureCollectTime(ch, "mongodb", "oplog_ops")()
+
+	logger := d.base.logger
+	client := d.base.client
+	if client == nil {
+		return
+	}
+
+	if err := d.tracker.read(d.ctx, client); err != nil {
+		// Standalone instances don't have an oplog.
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			logger.Debug("oplog is empty or does not exist")
+			return
+		}
+		logger.Error("cannot read the oplog", "error", err)
+	}
+
+	for _, metric := range d.tracker.metrics(d.topologyInfo.baseLabels()) {
+		ch <- metric
+	}
+}
+
+var _ prometheus.Collector = (*oplogOpsCollector)(nil)
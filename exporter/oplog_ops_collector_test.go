@@ -0,0 +1,88 @@
+// mongodb_exporter
+// Copyright (C) 2017 Percona LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/promslog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/percona/mongodb_exporter/internal/tu"
+)
+
+func TestOplogOpsCollector(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	client := tu.DefaultTestClient(ctx, t)
+	tracker := newOplogOpsTracker(10)
+
+	// The first read only records the position in the oplog.
+	require.NoError(t, tracker.read(ctx, client))
+
+	coll := client.Database("testdb").Collection("oplog_ops")
+	t.Cleanup(func() {
+		coll.Drop(ctx) //nolint:errcheck
+	})
+	_, err := coll.InsertMany(ctx, []interface{}{bson.M{"f": 1}, bson.M{"f": 2}})
+	require.NoError(t, err)
+
+	c := newOplogOpsCollector(ctx, client, promslog.New(&promslog.Config{}), labelsGetterMock{}, tracker)
+	assert.NotZero(t, testutil.CollectAndCount(c, "mongodb_oplog_ops_total"))
+
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+	assert.InDelta(t, 2, tracker.entries[oplogOpKey{ns: "testdb.oplog_ops", op: "i"}], 0)
+}
+
+func TestOplogOpsTracker(t *testing.T) {
+	t.Parallel()
+
+	tracker := newOplogOpsTracker(2)
+	tracker.add("db.a", "i", 100)
+	tracker.add("db.a", "i", 50)
+	tracker.add("db.b", "u", 10)
+	// Over the namespaces limit.
+	tracker.add("db.c", "d", 20)
+	tracker.add("db.d", "d", 30)
+	tracker.last = primitive.Timestamp{T: 1700000000, I: 1}
+
+	expected := strings.NewReader(`
+	# HELP mongodb_oplog_ops_bytes_total Approximate size of the oplog entries read since the exporter started, by namespace and operation
+	# TYPE mongodb_oplog_ops_bytes_total counter
+	mongodb_oplog_ops_bytes_total{ns="_other",op="d"} 50
+	mongodb_oplog_ops_bytes_total{ns="db.a",op="i"} 150
+	mongodb_oplog_ops_bytes_total{ns="db.b",op="u"} 10
+	# HELP mongodb_oplog_ops_last_timestamp_seconds Timestamp of the last oplog entry read by the oplog ops collector
+	# TYPE mongodb_oplog_ops_last_timestamp_seconds gauge
+	mongodb_oplog_ops_last_timestamp_seconds 1.7e+09
+	# HELP mongodb_oplog_ops_total Number of oplog entries read since the exporter started, by namespace and operation
+	# TYPE mongodb_oplog_ops_total counter
+	mongodb_oplog_ops_total{ns="_other",op="d"} 2
+	mongodb_oplog_ops_total{ns="db.a",op="i"} 2
+	mongodb_oplog_ops_total{ns="db.b",op="u"} 1` + "\n")
+	require.NoError(t, testutil.CollectAndCompare(metricsCollector(tracker.metrics(nil)), expected))
+
+	assert.Equal(t, DefaultOplogOpsMaxNamespaces, newOplogOpsTracker(0).maxNamespaces)
+}
@@ -0,0 +1,211 @@
+// mongodb_exporter
+// Copyright (C) 2017 Percona LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// rangeDeletionTask is a document of config.rangeDeletions on a shard. NumOrphanDocs
+// is set since 6.0 and Timestamp since 5.0.
+type rangeDeletionTask struct {
+	NSS           string              `bson:"nss"`
+	NumOrphanDocs int64               `bson:"numOrphanDocs"`
+	Timestamp     primitive.Timestamp `bson:"timestamp"`
+	Pending       bool                `bson:"pending"`
+}
+
+// shardedDataDistribution is a document of the $shardedDataDistribution stage.
+type shardedDataDistribution struct {
+	NS     string                  `bson:"ns"`
+	Shards []shardDataDistribution `bson:"shards"`
+}
+
+// shardDataDistribution is the data of a collection on a shard.
+type shardDataDistribution struct {
+	ShardName         string `bson:"shardName"`
+	NumOrphanedDocs   int64  `bson:"numOrphanedDocs"`
+	OrphanedSizeBytes int64  `bson:"orphanedSizeBytes"`
+}
+
+type rangeDeletionsCollector struct {
+	ctx  context.Context
+	base *baseCollector
+
+	topologyInfo labelsGetter
+	nodeType     mongoDBNodeType
+}
+
+// newRangeDeletionsCollector creates a collector for the range deletion tasks of a
+// shard member or, through a mongos, the orphaned documents of the cluster.
+func newRangeDeletionsCollector(ctx context.Context, client *mongo.Client, logger *slog.Logger, topology labelsGetter, nodeType mongoDBNodeType) *rangeDeletionsCollector {
+	return &rangeDeletionsCollector{
+		ctx:  ctx,
+		base: newBaseCollector(client, logger.With("collector", "range_deletions")),
+
+		topologyInfo: topology,
+		nodeType:     nodeType,
+	}
+}
+
+func (d *rangeDeletionsCollector) Describe(ch chan<- *prometheus.Desc) {
+	d.base.Describe(d.ctx, ch, d.collect)
+}
+
+func (d *rangeDeletionsCollector) Collect(ch chan<- prometheus.Metric) {
+	d.base.Collect(ch)
+}
+
+func (d *rangeDeletionsCollector) collect(ch chan<- prometheus.Metric) {
+	defer measureCollectTime(ch, "mongodb", "range_deletions")()
+
+	logger := d.base.logger
+	client := d.base.client
+	if client == nil {
+		return
+	}
+
+	labels := d.topologyInfo.baseLabels()
+	var metrics []prometheus.Metric
+
+	// $shardedDataDistribution only runs on mongos, config.rangeDeletions only
+	// exists on the shards.
+	if d.nodeType == typeMongos {
+		distribution, err := orphanedDocuments(d.ctx, client)
+		if err != nil {
+			logger.Warn("cannot run $shardedDataDistribution", "error", err)
+			return
+		}
+		metrics = orphanedDocumentsMetrics(distribution, labels)
+	} else {
+		cursor, err := client.Database("config").Collection("rangeDeletions").Find(d.ctx, bson.M{})
+		if err != nil {
+			logger.Error("cannot read config.rangeDeletions", "error", err)
+			return
+		}
+
+		var tasks []rangeDeletionTask
+		if err := cursor.All(d.ctx, &tasks); err != nil {
+			logger.Error("cannot decode config.rangeDeletions", "error", err)
+			return
+		}
+		metrics = rangeDeletionMetrics(tasks, time.Now(), labels)
+	}
+
+	for _, metric := range metrics {
+		ch <- metric
+	}
+}
+
+// orphanedDocuments returns the data distribution of the sharded collections, with
+// the orphaned documents of each shard. It needs MongoDB 6.0.3 or later.
+func orphanedDocuments(ctx context.Context, client *mongo.Client) ([]shardedDataDistribution, error) {
+	pipeline := bson.A{bson.M{"$shardedDataDistribution": bson.M{}}}
+
+	cursor, err := client.Database("admin").Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+
+	var distribution []shardedDataDistribution
+	if err := cursor.All(ctx, &distribution); err != nil {
+		return nil, fmt.Errorf("cannot decode $shardedDataDistribution: %w", err)
+	}
+
+	return distribution, nil
+}
+
+// rangeDeletionMetrics makes the number of tasks and orphaned documents waiting for
+// deletion per namespace and the age of the oldest task. Pending tasks belong to
+// migrations not committed yet and are not counted.
+func rangeDeletionMetrics(tasks []rangeDeletionTask, now time.Time, labels map[string]string) []prometheus.Metric {
+	tasksDesc := prometheus.NewDesc("mongodb_range_deletions_tasks",
+		"Number of range deletion tasks waiting on the shard (config.rangeDeletions)", []string{"ns"}, labels)
+	docsDesc := prometheus.NewDesc("mongodb_range_deletions_docs",
+		"Number of orphaned documents left to delete by the range deletion tasks of the shard", []string{"ns"}, labels)
+	totalDesc := prometheus.NewDesc("mongodb_range_deletions_shard_tasks",
+		"Number of range deletion tasks waiting on the shard, of all the namespaces", nil, labels)
+	oldestDesc := prometheus.NewDesc("mongodb_range_deletions_oldest_task_age_seconds",
+		"Age of the oldest range deletion task waiting on the shard", nil, labels)
+
+	counts := make(map[string]float64)
+	docs := make(map[string]float64)
+	var oldest primitive.Timestamp
+	total := 0
+
+	for _, t := range tasks {
+		if t.Pending {
+			continue
+		}
+
+		total++
+		counts[t.NSS]++
+		docs[t.NSS] += float64(t.NumOrphanDocs)
+		if !t.Timestamp.IsZero() && (oldest.IsZero() || t.Timestamp.Before(oldest)) {
+			oldest = t.Timestamp
+		}
+	}
+
+	metrics := make([]prometheus.Metric, 0, 2*len(counts)+2) //nolint:mnd
+	for _, ns := range slices.Sorted(maps.Keys(counts)) {
+		metrics = append(metrics,
+			prometheus.MustNewConstMetric(tasksDesc, prometheus.GaugeValue, counts[ns], ns),
+			prometheus.MustNewConstMetric(docsDesc, prometheus.GaugeValue, docs[ns], ns),
+		)
+	}
+
+	metrics = append(metrics, prometheus.MustNewConstMetric(totalDesc, prometheus.GaugeValue, float64(total)))
+
+	if !oldest.IsZero() {
+		age := now.Sub(time.Unix(int64(oldest.T), 0)).Seconds()
+		metrics = append(metrics, prometheus.MustNewConstMetric(oldestDesc, prometheus.GaugeValue, max(0, age)))
+	}
+
+	return metrics
+}
+
+// orphanedDocumentsMetrics makes the number and size of the orphaned documents of the
+// sharded collections by shard.
+func orphanedDocumentsMetrics(distribution []shardedDataDistribution, labels map[string]string) []prometheus.Metric {
+	docsDesc := prometheus.NewDesc("mongodb_range_deletions_orphaned_docs",
+		"Number of orphaned documents of the collection on the shard ($shardedDataDistribution)", []string{"ns", "shard"}, labels)
+	sizeDesc := prometheus.NewDesc("mongodb_range_deletions_orphaned_size_bytes",
+		"Size of the orphaned documents of the collection on the shard ($shardedDataDistribution)", []string{"ns", "shard"}, labels)
+
+	var metrics []prometheus.Metric
+	for _, d := range distribution {
+		for _, s := range d.Shards {
+			metrics = append(metrics,
+				prometheus.MustNewConstMetric(docsDesc, prometheus.GaugeValue, float64(s.NumOrphanedDocs), d.NS, s.ShardName),
+				prometheus.MustNewConstMetric(sizeDesc, prometheus.GaugeValue, float64(s.OrphanedSizeBytes), d.NS, s.ShardName),
+			)
+		}
+	}
+
+	return metrics
+}
+
+var _ prometheus.Collector = (*rangeDeletionsCollector)(nil)
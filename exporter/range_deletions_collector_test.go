@@ -0,0 +1,83 @@
+// mongodb_exporter
+// Copyright (C) 2017 Percona LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestRangeDeletionMetrics(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	ts := func(ago time.Duration) primitive.Timestamp {
+		return primitive.Timestamp{T: uint32(now.Add(-ago).Unix()), I: 1}
+	}
+
+	tasks := []rangeDeletionTask{
+		{NSS: "test.users", NumOrphanDocs: 100, Timestamp: ts(time.Hour)},
+		{NSS: "test.users", NumOrphanDocs: 20, Timestamp: ts(time.Minute)},
+		{NSS: "test.orders", NumOrphanDocs: 5, Timestamp: ts(10 * time.Minute)},
+		// The migration of pending tasks is not committed yet.
+		{NSS: "test.orders", NumOrphanDocs: 1000, Timestamp: ts(2 * time.Hour), Pending: true},
+	}
+
+	expected := strings.NewReader(`
+	# HELP mongodb_range_deletions_docs Number of orphaned documents left to delete by the range deletion tasks of the shard
+	# TYPE mongodb_range_deletions_docs gauge
+	mongodb_range_deletions_docs{ns="test.orders"} 5
+	mongodb_range_deletions_docs{ns="test.users"} 120
+	# HELP mongodb_range_deletions_oldest_task_age_seconds Age of the oldest range deletion task waiting on the shard
+	# TYPE mongodb_range_deletions_oldest_task_age_seconds gauge
+	mongodb_range_deletions_oldest_task_age_seconds 3600
+	# HELP mongodb_range_deletions_shard_tasks Number of range deletion tasks waiting on the shard, of all the namespaces
+	# TYPE mongodb_range_deletions_shard_tasks gauge
+	mongodb_range_deletions_shard_tasks 3
+	# HELP mongodb_range_deletions_tasks Number of range deletion tasks waiting on the shard (config.rangeDeletions)
+	# TYPE mongodb_range_deletions_tasks gauge
+	mongodb_range_deletions_tasks{ns="test.orders"} 1
+	mongodb_range_deletions_tasks{ns="test.users"} 2` + "\n")
+	require.NoError(t, testutil.CollectAndCompare(metricsCollector(rangeDeletionMetrics(tasks, now, nil)), expected))
+}
+
+func TestOrphanedDocumentsMetrics(t *testing.T) {
+	t.Parallel()
+
+	distribution := []shardedDataDistribution{{
+		NS: "test.users",
+		Shards: []shardDataDistribution{
+			{ShardName: "rs1", NumOrphanedDocs: 10, OrphanedSizeBytes: 2048},
+			{ShardName: "rs2"},
+		},
+	}}
+
+	expected := strings.NewReader(`
+	# HELP mongodb_range_deletions_orphaned_docs Number of orphaned documents of the collection on the shard ($shardedDataDistribution)
+	# TYPE mongodb_range_deletions_orphaned_docs gauge
+	mongodb_range_deletions_orphaned_docs{ns="test.users",shard="rs1"} 10
+	mongodb_range_deletions_orphaned_docs{ns="test.users",shard="rs2"} 0
+	# HELP mongodb_range_deletions_orphaned_size_bytes Size of the orphaned documents of the collection on the shard ($shardedDataDistribution)
+	# TYPE mongodb_range_deletions_orphaned_size_bytes gauge
+	mongodb_range_deletions_orphaned_size_bytes{ns="test.users",shard="rs1"} 2048
+	mongodb_range_deletions_orphaned_size_bytes{ns="test.users",shard="rs2"} 0` + "\n")
+	require.NoError(t, testutil.CollectAndCompare(metricsCollector(orphanedDocumentsMetrics(distribution, nil)), expected))
+}
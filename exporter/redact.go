@@ -0,0 +1,181 @@
+// mongodb_exporter
+// Copyright (C) 2017 Percona LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"context"
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+const redactedValue = "***"
+
+//nolint:gochecknoglobals
+var (
+	// Matches the user info of MongoDB URIs, capturing the scheme and the user name.
+	// The password is everything up to the last @ of the URI.
+	uriCredentialsRe = regexp.MustCompile(`(mongodb(?:\+srv)?://[^:/@\s"']*):[^\s"']*@`)
+
+	// Lowercased names of getCmdLineOpts fields (in parsed or as argv flags) which
+	// hold secrets or paths to secrets. Any field containing "password" is masked too.
+	sensitiveFields = map[string]struct{}{
+		"keyfile":           {},
+		"tokenfile":         {},
+		"token":             {},
+		"encryptionkeyfile": {},
+		"vaulttokenfile":    {},
+	}
+)
+
+// RedactURI masks the passwords of all the MongoDB URIs found in s.
+func RedactURI(s string) string {
+	if !strings.Contains(s, "mongodb") {
+		return s
+	}
+
+	return uriCredentialsRe.ReplaceAllString(s, "$1:"+redactedValue+"@")
+}
+
+func isSensitiveField(name string) bool {
+	name = strings.ToLower(strings.TrimLeft(name, "-"))
+	if strings.Contains(name, "password") {
+		return true
+	}
+	_, ok := sensitiveFields[name]
+
+	return ok
+}
+
+// redactDocument returns a copy of a document decoded from JSON (maps, slices and
+// scalars) with the sensitive fields and URI credentials masked.
+func redactDocument(v any) any {
+	switch val := v.(type) {
+	case map[string]any:
+		res := make(map[string]any, len(val))
+		for k, item := range val {
+			if isSensitiveField(k) {
+				res[k] = redactedValue
+				continue
+			}
+			res[k] = redactDocument(item)
+		}
+
+		return res
+	case []any:
+		return redactArgs(val)
+	case string:
+		return RedactURI(val)
+	default:
+		return v
+	}
+}
+
+// redactArgs redacts a list. Command line arguments like the getCmdLineOpts argv
+// field are handled both in the "--flag value" and in the "--flag=value" forms.
+func redactArgs(args []any) []any {
+	res := make([]any, len(args))
+	maskNext := false
+
+	for i, item := range args {
+		s, ok := item.(string)
+		switch {
+		case maskNext:
+			res[i] = redactedValue
+			maskNext = false
+		case ok && strings.HasPrefix(s, "-"):
+			if name, _, found := strings.Cut(s, "="); found && isSensitiveField(name) {
+				res[i] = name + "=" + redactedValue
+			} else {
+				res[i] = s
+				maskNext = !found && isSensitiveField(s)
+			}
+		default:
+			res[i] = redactDocument(item)
+		}
+	}
+
+	return res
+}
+
+// redactingHandler is a slog.Handler masking the URI credentials found in log
+// messages, string attributes and errors before passing them to the next handler.
+type redactingHandler struct {
+	next slog.Handler
+}
+
+// NewRedactingHandler wraps a slog.Handler to redact credentials from all the records.
+func NewRedactingHandler(next slog.Handler) slog.Handler {
+	if _, ok := next.(*redactingHandler); ok {
+		return next
+	}
+
+	return &redactingHandler{next: next}
+}
+
+func (h *redactingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *redactingHandler) Handle(ctx context.Context, r slog.Record) error {
+	nr := slog.NewRecord(r.Time, r.Level, RedactURI(r.Message), r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		nr.AddAttrs(redactAttr(a))
+		return true
+	})
+
+	return h.next.Handle(ctx, nr)
+}
+
+func (h *redactingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		redacted[i] = redactAttr(a)
+	}
+
+	return &redactingHandler{next: h.next.WithAttrs(redacted)}
+}
+
+func (h *redactingHandler) WithGroup(name string) slog.Handler {
+	return &redactingHandler{next: h.next.WithGroup(name)}
+}
+
+func redactAttr(a slog.Attr) slog.Attr {
+	v := a.Value.Resolve()
+
+	switch v.Kind() { //nolint:exhaustive
+	case slog.KindString:
+		return slog.String(a.Key, RedactURI(v.String()))
+	case slog.KindGroup:
+		group := v.Group()
+		redacted := make([]any, len(group))
+		for i, ga := range group {
+			redacted[i] = redactAttr(ga)
+		}
+
+		return slog.Group(a.Key, redacted...)
+	case slog.KindAny:
+		// Errors are only replaced when they contain credentials to keep their
+		// original formatting otherwise.
+		if err, ok := v.Any().(error); ok && err != nil {
+			if msg := err.Error(); RedactURI(msg) != msg {
+				return slog.String(a.Key, RedactURI(msg))
+			}
+		}
+	}
+
+	return slog.Attr{Key: a.Key, Value: v}
+}
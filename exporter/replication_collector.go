@@ -0,0 +1,441 @@
+// mongodb_exporter
+// Copyright (C) 2017 Percona LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/percona/mongodb_exporter/internal/proto"
+	"github.com/percona/mongodb_exporter/internal/util"
+)
+
+// Labels of the per member replication metrics.
+var replicationMemberLabels = []string{"member", "hidden", "delayed", "priority", "votes"}
+
+type replicationCollector struct {
+	ctx  context.Context
+	base *baseCollector
+
+	topologyInfo labelsGetter
+	// Member tag (for example the data center) a member should share with its
+	// sync source. Empty to not check the sync sources.
+	syncSourceTag string
+	// Largest difference between the lag and the configured delay of a delayed member.
+	delayTolerance time.Duration
+}
+
+// newReplicationCollector creates a collector for the replication lag and health of
+// the replica set members.
+func newReplicationCollector(ctx context.Context, client *mongo.Client, logger *slog.Logger, topology labelsGetter, syncSourceTag string, delayTolerance time.Duration) *replicationCollector {
+	return &replicationCollector{
+		ctx:  ctx,
+		base: newBaseCollector(client, logger.With("collector", "replication")),
+
+		topologyInfo:   topology,
+		syncSourceTag:  syncSourceTag,
+		delayTolerance: delayTolerance,
+	}
+}
+
+func (d *replicationCollector) Describe(ch chan<- *prometheus.Desc) {
+	d.base.Describe(d.ctx, ch, d.collect)
+}
+
+func (d *replicationCollector) Collect(ch chan<- prometheus.Metric) {
+	d.base.Collect(ch)
+}
+
+func (d *replicationCollector) collect(ch chan<- prometheus.Metric) {
+	defer measureCollectTime(ch, "mongodb", "replication")()
+
+	logger := d.base.logger
+	client := d.base.client
+	if client == nil {
+		return
+	}
+
+	status, err := util.ReplicasetStatus(d.ctx, client)
+	if err != nil {
+		var e mongo.CommandError
+		if errors.As(err, &e) && util.IsReplicationNotEnabledError(e) {
+			return
+		}
+		logger.Error("cannot get replSetGetStatus", "error", err)
+
+		return
+	}
+
+	rs, err := util.ReplicasetConfig(d.ctx, client)
+	if err != nil {
+		logger.Error("cannot get replSetGetConfig", "error", err)
+
+		return
+	}
+
+	labels := d.topologyInfo.baseLabels()
+	metrics := replicationMetrics(status, &rs.Config, labels)
+	metrics = append(metrics, majorityMetrics(status, &rs.Config, labels)...)
+	metrics = append(metrics, syncSourceMetrics(status, &rs.Config, d.syncSourceTag, labels)...)
+	metrics = append(metrics, delayMetrics(status, &rs.Config, d.delayTolerance, labels)...)
+
+	for _, metric := range metrics {
+		ch <- metric
+	}
+}
+
+// replicationMetrics makes the per member metrics from the replica set status,
+// labelled with the attributes of the members in the replica set config.
+func replicationMetrics(status *proto.ReplicaSetStatus, config *proto.RSConfig, labels map[string]string) []prometheus.Metric {
+	configMembers := make(map[string]proto.Member, len(config.Members))
+	for _, m := range config.Members {
+		configMembers[m.Host] = m
+	}
+
+	primaryOptime := primaryOptimeDate(status)
+
+	newDesc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc("mongodb_rs_member_"+name, help, replicationMemberLabels, labels)
+	}
+
+	healthDesc := newDesc("health", "Health of the member as seen by this node (1 = up, 0 = down)")
+	stateDesc := newDesc("state", "Replica set state of the member")
+	lagDesc := newDesc("replication_lag_seconds", "Time between the last operation applied by the primary and by the member")
+	durableLagDesc := newDesc("optime_durable_lag_seconds", "Time between the last operation applied and the last operation written to the journal by the member")
+	heartbeatDesc := newDesc("heartbeat_age_seconds", "Time since the last heartbeat response received from the member")
+	pingDesc := newDesc("ping_seconds", "Round trip time of the heartbeats between this node and the member (pingMs)")
+
+	var metrics []prometheus.Metric
+
+	for _, m := range status.Members {
+		values := memberLabelValues(m.Name, configMembers[m.Name])
+
+		metrics = append(metrics,
+			prometheus.MustNewConstMetric(healthDesc, prometheus.GaugeValue, m.Health, values...),
+			prometheus.MustNewConstMetric(stateDesc, prometheus.GaugeValue, m.State, values...),
+		)
+
+		// Arbiters and unreachable members don't report an optime.
+		if m.OptimeDate != 0 {
+			if primaryOptime != 0 {
+				lag := primaryOptime.Time().Sub(m.OptimeDate.Time()).Seconds()
+				metrics = append(metrics, prometheus.MustNewConstMetric(lagDesc, prometheus.GaugeValue, lag, values...))
+			}
+
+			if m.OptimeDurableDate != 0 {
+				lag := m.OptimeDate.Time().Sub(m.OptimeDurableDate.Time()).Seconds()
+				metrics = append(metrics, prometheus.MustNewConstMetric(durableLagDesc, prometheus.GaugeValue, lag, values...))
+			}
+		}
+
+		// There are no heartbeats to the node itself.
+		if !m.Self && m.LastHeartbeat != 0 {
+			age := status.Date.Time().Sub(m.LastHeartbeat.Time()).Seconds()
+			metrics = append(metrics, prometheus.MustNewConstMetric(heartbeatDesc, prometheus.GaugeValue, age, values...))
+		}
+
+		if m.PingMs != nil {
+			ping := time.Duration(*m.PingMs * float64(time.Millisecond)).Seconds()
+			metrics = append(metrics, prometheus.MustNewConstMetric(pingDesc, prometheus.GaugeValue, ping, values...))
+		}
+	}
+
+	return metrics
+}
+
+// delayMetrics makes the metrics comparing the replication lag of the members to
+// their configured delay. Since the primary writes a no-op entry every few seconds
+// when idle, the lag of a healthy delayed member stays close to its delay: a lag out
+// of the tolerance on either side means the delay is not applied or the member is
+// stuck.
+func delayMetrics(status *proto.ReplicaSetStatus, config *proto.RSConfig, tolerance time.Duration, labels map[string]string) []prometheus.Metric {
+	configMembers := make(map[string]proto.Member, len(config.Members))
+	for _, m := range config.Members {
+		configMembers[m.Host] = m
+	}
+
+	primaryOptime := primaryOptimeDate(status)
+
+	newDesc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc("mongodb_rs_member_"+name, help, replicationMemberLabels, labels)
+	}
+
+	configuredDesc := newDesc("configured_delay_seconds", "Replication delay configured for the member (secondaryDelaySecs or slaveDelay)")
+	overDelayDesc := newDesc("lag_over_delay_seconds", "Replication lag of the delayed member minus its configured delay")
+	compliantDesc := newDesc("delay_compliant", "1 if the replication lag of the delayed member is within the tolerance of its configured delay")
+	configCompliantDesc := newDesc("delay_config_compliant", "1 if the delayed member is hidden with priority 0, so it can neither become primary nor serve reads")
+
+	var metrics []prometheus.Metric
+
+	for _, m := range status.Members {
+		cm, ok := configMembers[m.Name]
+		if !ok {
+			continue
+		}
+
+		delay := memberDelaySecs(cm)
+		values := memberLabelValues(m.Name, cm)
+		metrics = append(metrics, prometheus.MustNewConstMetric(configuredDesc, prometheus.GaugeValue, float64(delay), values...))
+
+		if delay > 0 {
+			configCompliant := 0.0
+			if cm.Hidden && cm.Priority == 0 {
+				configCompliant = 1
+			}
+			metrics = append(metrics, prometheus.MustNewConstMetric(configCompliantDesc, prometheus.GaugeValue, configCompliant, values...))
+		}
+
+		if delay == 0 || m.OptimeDate == 0 || primaryOptime == 0 {
+			continue
+		}
+
+		overDelay := primaryOptime.Time().Sub(m.OptimeDate.Time()).Seconds() - float64(delay)
+		metrics = append(metrics, prometheus.MustNewConstMetric(overDelayDesc, prometheus.GaugeValue, overDelay, values...))
+
+		compliant := 0.0
+		if math.Abs(overDelay) <= tolerance.Seconds() {
+			compliant = 1
+		}
+		metrics = append(metrics, prometheus.MustNewConstMetric(compliantDesc, prometheus.GaugeValue, compliant, values...))
+	}
+
+	return metrics
+}
+
+// primaryOptimeDate returns the optime of the primary, or zero if there is none.
+func primaryOptimeDate(status *proto.ReplicaSetStatus) primitive.DateTime {
+	for _, m := range status.Members {
+		if m.State == PrimaryState {
+			return m.OptimeDate
+		}
+	}
+
+	return 0
+}
+
+// majorityMetrics makes the metrics about the majority commit point and how many
+// members can be lost before w:majority writes stall.
+func majorityMetrics(status *proto.ReplicaSetStatus, config *proto.RSConfig, labels map[string]string) []prometheus.Metric {
+	gauge := func(name, help string, value float64) prometheus.Metric {
+		desc := prometheus.NewDesc("mongodb_rs_"+name, help, nil, labels)
+		return prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, value)
+	}
+
+	votes := make(map[string]int32, len(config.Members))
+	votingMembers, writableVotingMembers := 0, 0
+	for _, m := range config.Members {
+		votes[m.Host] = m.Votes
+		if m.Votes > 0 {
+			votingMembers++
+			if !m.ArbiterOnly {
+				writableVotingMembers++
+			}
+		}
+	}
+
+	healthyVoting, healthyWritableVoting := 0, 0
+	for _, m := range status.Members {
+		if m.Health != 1 || votes[m.Name] == 0 {
+			continue
+		}
+		switch m.State {
+		case PrimaryState, SecondaryState:
+			healthyVoting++
+			healthyWritableVoting++
+		case ArbiterState:
+			healthyVoting++
+		}
+	}
+
+	// Older versions don't report the counts, they are computed like the server does.
+	majorityVoteCount := status.MajorityVoteCount
+	if majorityVoteCount == 0 {
+		majorityVoteCount = votingMembers/2 + 1
+	}
+	writeMajorityCount := status.WriteMajorityCount
+	if writeMajorityCount == 0 {
+		writeMajorityCount = min(majorityVoteCount, writableVotingMembers)
+	}
+
+	// Majority writes are at risk when losing one more member stalls them.
+	atRisk := 0.0
+	if healthyWritableVoting <= writeMajorityCount {
+		atRisk = 1
+	}
+
+	metrics := []prometheus.Metric{
+		gauge("healthy_voting_members", "Number of healthy voting members, including arbiters", float64(healthyVoting)),
+		gauge("healthy_writable_voting_members", "Number of healthy data bearing voting members able to acknowledge w:majority writes", float64(healthyWritableVoting)),
+		gauge("majority_vote_count", "Number of votes needed to elect a primary", float64(majorityVoteCount)),
+		gauge("write_majority_count", "Number of data bearing voting members needed to acknowledge w:majority writes", float64(writeMajorityCount)),
+		gauge("majority_writes_at_risk", "1 if losing one more data bearing voting member stalls w:majority writes (or they are already stalled)", atRisk),
+	}
+
+	if lag, ok := majorityCommitLag(status.Optimes); ok {
+		metrics = append(metrics, gauge("majority_commit_lag_seconds",
+			"Time between the last operation applied by this member and the majority commit point", lag))
+	}
+
+	return metrics
+}
+
+// majorityCommitLag returns the time between the last applied operation and the
+// majority commit point, using the wall times when they are available (4.2+).
+func majorityCommitLag(optimes proto.ReplicaSetOptimes) (float64, bool) {
+	if optimes.LastAppliedWallTime != 0 && optimes.LastCommittedWallTime != 0 {
+		return optimes.LastAppliedWallTime.Time().Sub(optimes.LastCommittedWallTime.Time()).Seconds(), true
+	}
+
+	if optimes.AppliedOpTime.Ts.T != 0 && optimes.LastCommittedOpTime.Ts.T != 0 {
+		return float64(optimes.AppliedOpTime.Ts.T) - float64(optimes.LastCommittedOpTime.Ts.T), true
+	}
+
+	return 0, false
+}
+
+// syncSourceMetrics makes the metrics describing the replication graph: the sync
+// source of each member, how many hops the member is from the primary and, if a
+// tag is given, whether the member syncs from a source with a different tag value
+// while a healthy member with the same value is available.
+func syncSourceMetrics(status *proto.ReplicaSetStatus, config *proto.RSConfig, tag string, labels map[string]string) []prometheus.Metric {
+	sourceDesc := prometheus.NewDesc("mongodb_rs_member_sync_source", "Sync source of the member",
+		[]string{"member", "sync_source"}, labels)
+	depthDesc := prometheus.NewDesc("mongodb_rs_member_sync_chain_depth",
+		"Number of replication hops between the primary and the member (0 for the primary)", []string{"member"}, labels)
+	nonPreferredDesc := prometheus.NewDesc("mongodb_rs_member_sync_source_non_preferred",
+		"1 if the member syncs from a source with a different sync source tag value while a healthy member with the same value is available",
+		[]string{"member", "sync_source"}, labels)
+
+	sources := make(map[string]string, len(status.Members))
+	primary := ""
+	for _, m := range status.Members {
+		if m.State == PrimaryState {
+			primary = m.Name
+		}
+		if source := memberSyncSource(m); source != "" {
+			sources[m.Name] = source
+		}
+	}
+
+	var metrics []prometheus.Metric
+
+	for _, m := range status.Members {
+		source := sources[m.Name]
+		if source != "" {
+			metrics = append(metrics, prometheus.MustNewConstMetric(sourceDesc, prometheus.GaugeValue, 1, m.Name, source))
+		}
+
+		if depth, ok := syncChainDepth(m.Name, primary, sources); ok {
+			metrics = append(metrics, prometheus.MustNewConstMetric(depthDesc, prometheus.GaugeValue, float64(depth), m.Name))
+		}
+
+		if tag != "" && source != "" {
+			value := 0.0
+			if isNonPreferredSyncSource(status, config, tag, m.Name, source) {
+				value = 1
+			}
+			metrics = append(metrics, prometheus.MustNewConstMetric(nonPreferredDesc, prometheus.GaugeValue, value, m.Name, source))
+		}
+	}
+
+	return metrics
+}
+
+func memberSyncSource(m proto.Members) string {
+	if m.SyncSourceHost != "" {
+		return m.SyncSourceHost
+	}
+
+	return m.SyncingTo
+}
+
+// syncChainDepth follows the sync sources from the member up to the primary. It
+// returns false if the chain is broken or has a loop.
+func syncChainDepth(member, primary string, sources map[string]string) (int, bool) {
+	if primary == "" {
+		return 0, false
+	}
+
+	depth := 0
+	for current := member; current != primary; depth++ {
+		source, ok := sources[current]
+		if !ok || depth > len(sources) {
+			return 0, false
+		}
+		current = source
+	}
+
+	return depth, true
+}
+
+// isNonPreferredSyncSource returns true if the member and its source have different
+// values for tag while a healthy data bearing member other than the member itself
+// has the same value as the member.
+func isNonPreferredSyncSource(status *proto.ReplicaSetStatus, config *proto.RSConfig, tag, member, source string) bool {
+	tags := make(map[string]string, len(config.Members))
+	for _, m := range config.Members {
+		if v, ok := m.Tags[tag]; ok {
+			tags[m.Host] = fmt.Sprint(v)
+		}
+	}
+
+	value, ok := tags[member]
+	if !ok || tags[source] == value {
+		return false
+	}
+
+	for _, m := range status.Members {
+		if m.Name == member || m.Health != 1 || (m.State != PrimaryState && m.State != SecondaryState) {
+			continue
+		}
+		if v, ok := tags[m.Name]; ok && v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// memberLabelValues returns the values of replicationMemberLabels.
+func memberLabelValues(name string, m proto.Member) []string {
+	return []string{
+		name,
+		strconv.FormatBool(m.Hidden),
+		strconv.FormatBool(memberDelaySecs(m) > 0),
+		strconv.FormatFloat(m.Priority, 'f', -1, 64),
+		strconv.Itoa(int(m.Votes)),
+	}
+}
+
+// memberDelaySecs returns the configured replication delay of a member.
+func memberDelaySecs(m proto.Member) int64 {
+	if m.SecondaryDelaySecs > 0 {
+		return m.SecondaryDelaySecs
+	}
+
+	return m.SlaveDelay
+}
+
+var _ prometheus.Collector = (*replicationCollector)(nil)
@@ -0,0 +1,286 @@
+// mongodb_exporter
+// Copyright (C) 2017 Percona LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/promslog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/percona/mongodb_exporter/internal/proto"
+	"github.com/percona/mongodb_exporter/internal/tu"
+)
+
+func TestReplicationCollector(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	client := tu.DefaultTestClient(ctx, t)
+
+	c := newReplicationCollector(ctx, client, promslog.New(&promslog.Config{}), labelsGetterMock{}, "", time.Minute)
+
+	// The test replica set has a primary and two secondaries.
+	assert.Equal(t, 3, testutil.CollectAndCount(c, "mongodb_rs_member_health"))
+	assert.Equal(t, 3, testutil.CollectAndCount(c, "mongodb_rs_member_replication_lag_seconds"))
+}
+
+func TestReplicationMetrics(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	ping := 2.0
+
+	status := &proto.ReplicaSetStatus{
+		Date: primitive.NewDateTimeFromTime(now),
+		Members: []proto.Members{
+			{
+				Name:              "mongo-1:27017",
+				State:             PrimaryState,
+				Health:            1,
+				Self:              true,
+				OptimeDate:        primitive.NewDateTimeFromTime(now),
+				OptimeDurableDate: primitive.NewDateTimeFromTime(now.Add(-time.Second)),
+			},
+			{
+				Name:              "mongo-2:27017",
+				State:             SecondaryState,
+				Health:            1,
+				OptimeDate:        primitive.NewDateTimeFromTime(now.Add(-time.Hour)),
+				OptimeDurableDate: primitive.NewDateTimeFromTime(now.Add(-time.Hour)),
+				LastHeartbeat:     primitive.NewDateTimeFromTime(now.Add(-3 * time.Second)),
+				PingMs:            &ping,
+			},
+			{
+				Name:          "mongo-3:27017",
+				State:         ArbiterState,
+				Health:        0,
+				LastHeartbeat: primitive.NewDateTimeFromTime(now.Add(-30 * time.Second)),
+			},
+		},
+	}
+
+	config := &proto.RSConfig{
+		Members: []proto.Member{
+			{Host: "mongo-1:27017", Priority: 1, Votes: 1},
+			{Host: "mongo-2:27017", Priority: 0, Votes: 0, Hidden: true, SecondaryDelaySecs: 3600},
+			{Host: "mongo-3:27017", Priority: 0, Votes: 1, ArbiterOnly: true},
+		},
+	}
+
+	metrics := replicationMetrics(status, config, map[string]string{"rs_nm": "rs1"})
+
+	expected := strings.NewReader(`
+	# HELP mongodb_rs_member_health Health of the member as seen by this node (1 = up, 0 = down)
+	# TYPE mongodb_rs_member_health gauge
+	mongodb_rs_member_health{delayed="false",hidden="false",member="mongo-1:27017",priority="1",rs_nm="rs1",votes="1"} 1
+	mongodb_rs_member_health{delayed="false",hidden="false",member="mongo-3:27017",priority="0",rs_nm="rs1",votes="1"} 0
+	mongodb_rs_member_health{delayed="true",hidden="true",member="mongo-2:27017",priority="0",rs_nm="rs1",votes="0"} 1
+	# HELP mongodb_rs_member_heartbeat_age_seconds Time since the last heartbeat response received from the member
+	# TYPE mongodb_rs_member_heartbeat_age_seconds gauge
+	mongodb_rs_member_heartbeat_age_seconds{delayed="false",hidden="false",member="mongo-3:27017",priority="0",rs_nm="rs1",votes="1"} 30
+	mongodb_rs_member_heartbeat_age_seconds{delayed="true",hidden="true",member="mongo-2:27017",priority="0",rs_nm="rs1",votes="0"} 3
+	# HELP mongodb_rs_member_optime_durable_lag_seconds Time between the last operation applied and the last operation written to the journal by the member
+	# TYPE mongodb_rs_member_optime_durable_lag_seconds gauge
+	mongodb_rs_member_optime_durable_lag_seconds{delayed="false",hidden="false",member="mongo-1:27017",priority="1",rs_nm="rs1",votes="1"} 1
+	mongodb_rs_member_optime_durable_lag_seconds{delayed="true",hidden="true",member="mongo-2:27017",priority="0",rs_nm="rs1",votes="0"} 0
+	# HELP mongodb_rs_member_ping_seconds Round trip time of the heartbeats between this node and the member (pingMs)
+	# TYPE mongodb_rs_member_ping_seconds gauge
+	mongodb_rs_member_ping_seconds{delayed="true",hidden="true",member="mongo-2:27017",priority="0",rs_nm="rs1",votes="0"} 0.002
+	# HELP mongodb_rs_member_replication_lag_seconds Time between the last operation applied by the primary and by the member
+	# TYPE mongodb_rs_member_replication_lag_seconds gauge
+	mongodb_rs_member_replication_lag_seconds{delayed="false",hidden="false",member="mongo-1:27017",priority="1",rs_nm="rs1",votes="1"} 0
+	mongodb_rs_member_replication_lag_seconds{delayed="true",hidden="true",member="mongo-2:27017",priority="0",rs_nm="rs1",votes="0"} 3600
+	# HELP mongodb_rs_member_state Replica set state of the member
+	# TYPE mongodb_rs_member_state gauge
+	mongodb_rs_member_state{delayed="false",hidden="false",member="mongo-1:27017",priority="1",rs_nm="rs1",votes="1"} 1
+	mongodb_rs_member_state{delayed="false",hidden="false",member="mongo-3:27017",priority="0",rs_nm="rs1",votes="1"} 7
+	mongodb_rs_member_state{delayed="true",hidden="true",member="mongo-2:27017",priority="0",rs_nm="rs1",votes="0"} 2` + "\n")
+	require.NoError(t, testutil.CollectAndCompare(metricsCollector(metrics), expected))
+}
+
+func TestMajorityMetrics(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	config := &proto.RSConfig{
+		Members: []proto.Member{
+			{Host: "mongo-1:27017", Votes: 1},
+			{Host: "mongo-2:27017", Votes: 1},
+			{Host: "mongo-3:27017", Votes: 1, ArbiterOnly: true},
+			{Host: "mongo-4:27017", Votes: 0},
+		},
+	}
+
+	// A primary, an arbiter, a non voting secondary and an unreachable secondary:
+	// the primary keeps its majority thanks to the arbiter but w:majority writes stall.
+	status := &proto.ReplicaSetStatus{
+		Members: []proto.Members{
+			{Name: "mongo-1:27017", State: PrimaryState, Health: 1},
+			{Name: "mongo-2:27017", State: UnknownState, Health: 0},
+			{Name: "mongo-3:27017", State: ArbiterState, Health: 1},
+			{Name: "mongo-4:27017", State: SecondaryState, Health: 1},
+		},
+		Optimes: proto.ReplicaSetOptimes{
+			LastAppliedWallTime:   primitive.NewDateTimeFromTime(now),
+			LastCommittedWallTime: primitive.NewDateTimeFromTime(now.Add(-90 * time.Second)),
+		},
+	}
+
+	expected := strings.NewReader(`
+	# HELP mongodb_rs_healthy_voting_members Number of healthy voting members, including arbiters
+	# TYPE mongodb_rs_healthy_voting_members gauge
+	mongodb_rs_healthy_voting_members 2
+	# HELP mongodb_rs_healthy_writable_voting_members Number of healthy data bearing voting members able to acknowledge w:majority writes
+	# TYPE mongodb_rs_healthy_writable_voting_members gauge
+	mongodb_rs_healthy_writable_voting_members 1
+	# HELP mongodb_rs_majority_commit_lag_seconds Time between the last operation applied by this member and the majority commit point
+	# TYPE mongodb_rs_majority_commit_lag_seconds gauge
+	mongodb_rs_majority_commit_lag_seconds 90
+	# HELP mongodb_rs_majority_vote_count Number of votes needed to elect a primary
+	# TYPE mongodb_rs_majority_vote_count gauge
+	mongodb_rs_majority_vote_count 2
+	# HELP mongodb_rs_majority_writes_at_risk 1 if losing one more data bearing voting member stalls w:majority writes (or they are already stalled)
+	# TYPE mongodb_rs_majority_writes_at_risk gauge
+	mongodb_rs_majority_writes_at_risk 1
+	# HELP mongodb_rs_write_majority_count Number of data bearing voting members needed to acknowledge w:majority writes
+	# TYPE mongodb_rs_write_majority_count gauge
+	mongodb_rs_write_majority_count 2` + "\n")
+	require.NoError(t, testutil.CollectAndCompare(metricsCollector(majorityMetrics(status, config, nil)), expected))
+
+	// Reported counts take precedence and the optimes are used without wall times.
+	status.MajorityVoteCount = 3
+	status.WriteMajorityCount = 1
+	status.Optimes = proto.ReplicaSetOptimes{
+		AppliedOpTime:       proto.Optime{Ts: primitive.Timestamp{T: 1000}},
+		LastCommittedOpTime: proto.Optime{Ts: primitive.Timestamp{T: 995}},
+	}
+
+	expected = strings.NewReader(`
+	# HELP mongodb_rs_majority_commit_lag_seconds Time between the last operation applied by this member and the majority commit point
+	# TYPE mongodb_rs_majority_commit_lag_seconds gauge
+	mongodb_rs_majority_commit_lag_seconds 5
+	# HELP mongodb_rs_majority_vote_count Number of votes needed to elect a primary
+	# TYPE mongodb_rs_majority_vote_count gauge
+	mongodb_rs_majority_vote_count 3` + "\n")
+	require.NoError(t, testutil.CollectAndCompare(metricsCollector(majorityMetrics(status, config, nil)), expected,
+		"mongodb_rs_majority_commit_lag_seconds", "mongodb_rs_majority_vote_count"))
+}
+
+func TestSyncSourceMetrics(t *testing.T) {
+	t.Parallel()
+
+	// mongo-3 syncs from mongo-2 in another data center while mongo-4 is in its own.
+	// mongo-5 and mongo-6 sync from each other.
+	status := &proto.ReplicaSetStatus{
+		Members: []proto.Members{
+			{Name: "mongo-1:27017", State: PrimaryState, Health: 1},
+			{Name: "mongo-2:27017", State: SecondaryState, Health: 1, SyncSourceHost: "mongo-1:27017"},
+			{Name: "mongo-3:27017", State: SecondaryState, Health: 1, SyncSourceHost: "mongo-2:27017"},
+			{Name: "mongo-4:27017", State: SecondaryState, Health: 1, SyncingTo: "mongo-1:27017"},
+			{Name: "mongo-5:27017", State: SecondaryState, Health: 1, SyncSourceHost: "mongo-6:27017"},
+			{Name: "mongo-6:27017", State: SecondaryState, Health: 1, SyncSourceHost: "mongo-5:27017"},
+		},
+	}
+
+	config := &proto.RSConfig{
+		Members: []proto.Member{
+			{Host: "mongo-1:27017", Tags: bson.M{"dc": "east"}},
+			{Host: "mongo-2:27017", Tags: bson.M{"dc": "east"}},
+			{Host: "mongo-3:27017", Tags: bson.M{"dc": "west"}},
+			{Host: "mongo-4:27017", Tags: bson.M{"dc": "west"}},
+			{Host: "mongo-5:27017"},
+			{Host: "mongo-6:27017"},
+		},
+	}
+
+	expected := strings.NewReader(`
+	# HELP mongodb_rs_member_sync_chain_depth Number of replication hops between the primary and the member (0 for the primary)
+	# TYPE mongodb_rs_member_sync_chain_depth gauge
+	mongodb_rs_member_sync_chain_depth{member="mongo-1:27017"} 0
+	mongodb_rs_member_sync_chain_depth{member="mongo-2:27017"} 1
+	mongodb_rs_member_sync_chain_depth{member="mongo-3:27017"} 2
+	mongodb_rs_member_sync_chain_depth{member="mongo-4:27017"} 1
+	# HELP mongodb_rs_member_sync_source Sync source of the member
+	# TYPE mongodb_rs_member_sync_source gauge
+	mongodb_rs_member_sync_source{member="mongo-2:27017",sync_source="mongo-1:27017"} 1
+	mongodb_rs_member_sync_source{member="mongo-3:27017",sync_source="mongo-2:27017"} 1
+	mongodb_rs_member_sync_source{member="mongo-4:27017",sync_source="mongo-1:27017"} 1
+	mongodb_rs_member_sync_source{member="mongo-5:27017",sync_source="mongo-6:27017"} 1
+	mongodb_rs_member_sync_source{member="mongo-6:27017",sync_source="mongo-5:27017"} 1
+	# HELP mongodb_rs_member_sync_source_non_preferred 1 if the member syncs from a source with a different sync source tag value while a healthy member with the same value is available
+	# TYPE mongodb_rs_member_sync_source_non_preferred gauge
+	mongodb_rs_member_sync_source_non_preferred{member="mongo-2:27017",sync_source="mongo-1:27017"} 0
+	mongodb_rs_member_sync_source_non_preferred{member="mongo-3:27017",sync_source="mongo-2:27017"} 1
+	mongodb_rs_member_sync_source_non_preferred{member="mongo-4:27017",sync_source="mongo-1:27017"} 1
+	mongodb_rs_member_sync_source_non_preferred{member="mongo-5:27017",sync_source="mongo-6:27017"} 0
+	mongodb_rs_member_sync_source_non_preferred{member="mongo-6:27017",sync_source="mongo-5:27017"} 0` + "\n")
+	require.NoError(t, testutil.CollectAndCompare(metricsCollector(syncSourceMetrics(status, config, "dc", nil)), expected))
+
+	// Without a tag the sync sources are not checked.
+	assert.Equal(t, 0, testutil.CollectAndCount(metricsCollector(syncSourceMetrics(status, config, "", nil)),
+		"mongodb_rs_member_sync_source_non_preferred"))
+}
+
+func TestDelayMetrics(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	status := &proto.ReplicaSetStatus{
+		Members: []proto.Members{
+			{Name: "mongo-1:27017", State: PrimaryState, OptimeDate: primitive.NewDateTimeFromTime(now)},
+			{Name: "mongo-2:27017", State: SecondaryState, OptimeDate: primitive.NewDateTimeFromTime(now.Add(-3630 * time.Second))},
+			// Delayed member applying the entries too early.
+			{Name: "mongo-3:27017", State: SecondaryState, OptimeDate: primitive.NewDateTimeFromTime(now.Add(-5 * time.Second))},
+		},
+	}
+	config := &proto.RSConfig{
+		Members: []proto.Member{
+			{Host: "mongo-1:27017", Priority: 1, Votes: 1},
+			{Host: "mongo-2:27017", Hidden: true, SecondaryDelaySecs: 3600},
+			{Host: "mongo-3:27017", SlaveDelay: 600, Votes: 1},
+		},
+	}
+
+	expected := strings.NewReader(`
+	# HELP mongodb_rs_member_configured_delay_seconds Replication delay configured for the member (secondaryDelaySecs or slaveDelay)
+	# TYPE mongodb_rs_member_configured_delay_seconds gauge
+	mongodb_rs_member_configured_delay_seconds{delayed="false",hidden="false",member="mongo-1:27017",priority="1",votes="1"} 0
+	mongodb_rs_member_configured_delay_seconds{delayed="true",hidden="false",member="mongo-3:27017",priority="0",votes="1"} 600
+	mongodb_rs_member_configured_delay_seconds{delayed="true",hidden="true",member="mongo-2:27017",priority="0",votes="0"} 3600
+	# HELP mongodb_rs_member_delay_compliant 1 if the replication lag of the delayed member is within the tolerance of its configured delay
+	# TYPE mongodb_rs_member_delay_compliant gauge
+	mongodb_rs_member_delay_compliant{delayed="true",hidden="false",member="mongo-3:27017",priority="0",votes="1"} 0
+	mongodb_rs_member_delay_compliant{delayed="true",hidden="true",member="mongo-2:27017",priority="0",votes="0"} 1
+	# HELP mongodb_rs_member_delay_config_compliant 1 if the delayed member is hidden with priority 0, so it can neither become primary nor serve reads
+	# TYPE mongodb_rs_member_delay_config_compliant gauge
+	mongodb_rs_member_delay_config_compliant{delayed="true",hidden="false",member="mongo-3:27017",priority="0",votes="1"} 0
+	mongodb_rs_member_delay_config_compliant{delayed="true",hidden="true",member="mongo-2:27017",priority="0",votes="0"} 1
+	# HELP mongodb_rs_member_lag_over_delay_seconds Replication lag of the delayed member minus its configured delay
+	# TYPE mongodb_rs_member_lag_over_delay_seconds gauge
+	mongodb_rs_member_lag_over_delay_seconds{delayed="true",hidden="false",member="mongo-3:27017",priority="0",votes="1"} -595
+	mongodb_rs_member_lag_over_delay_seconds{delayed="true",hidden="true",member="mongo-2:27017",priority="0",votes="0"} 30` + "\n")
+	require.NoError(t, testutil.CollectAndCompare(metricsCollector(delayMetrics(status, config, time.Minute, nil)), expected))
+}
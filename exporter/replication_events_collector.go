@@ -0,0 +1,202 @@
+// mongodb_exporter
+// Copyright (C) 2017 Percona LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/percona/mongodb_exporter/internal/proto"
+	"github.com/percona/mongodb_exporter/internal/util"
+)
+
+const electionReasonPriorityTakeover = "priorityTakeover"
+
+// rollbackTracker counts the rollbacks of a member between scrapes, from the
+// changes of its rollback ID (replSetGetRBID).
+type rollbackTracker struct {
+	mu        sync.Mutex
+	rbid      int64
+	known     bool
+	rollbacks float64
+}
+
+// observe records the current rollback ID and returns the number of rollbacks
+// seen since the exporter started.
+func (t *rollbackTracker) observe(rbid int64) float64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.known && rbid != t.rbid {
+		// The ID is incremented on each rollback. Any other change, for example
+		// after a resync, counts as a single rollback.
+		if rbid > t.rbid {
+			t.rollbacks += float64(rbid - t.rbid)
+		} else {
+			t.rollbacks++
+		}
+	}
+
+	t.rbid = rbid
+	t.known = true
+
+	return t.rollbacks
+}
+
+type replicationEventsCollector struct {
+	ctx  context.Context
+	base *baseCollector
+
+	topologyInfo labelsGetter
+	rollbacks    *rollbackTracker
+}
+
+// newReplicationEventsCollector creates a collector for the rollbacks and elections
+// of a replica set member.
+func newReplicationEventsCollector(ctx context.Context, client *mongo.Client, logger *slog.Logger, topology labelsGetter, rollbacks *rollbackTracker) *replicationEventsCollector {
+	return &replicationEventsCollector{
+		ctx:  ctx,
+		base: newBaseCollector(client, logger.With("collector", "replication_events")),
+
+		topologyInfo: topology,
+		rollbacks:    rollbacks,
+	}
+}
+
+func (d *replicationEventsCollector) Describe(ch chan<- *prometheus.Desc) {
+	d.base.Describe(d.ctx, ch, d.collect)
+}
+
+func (d *replicationEventsCollector) Collect(ch chan<- prometheus.Metric) {
+	d.base.Collect(ch)
+}
+
+func (d *replicationEventsCollector) collect(ch chan<- prometheus.Metric) {
+	defer measureCollectTime(ch, "mongodb", "replication_events")()
+
+	logger := d.base.logger
+	client := d.base.client
+	if client == nil {
+		return
+	}
+
+	status, err := util.ReplicasetStatus(d.ctx, client)
+	if err != nil {
+		var e mongo.CommandError
+		if errors.As(err, &e) && util.IsReplicationNotEnabledError(e) {
+			return
+		}
+		logger.Error("cannot get replSetGetStatus", "error", err)
+
+		return
+	}
+
+	labels := d.topologyInfo.baseLabels()
+	metrics := electionMetrics(status, labels)
+
+	var rbid struct {
+		RBID int64 `bson:"rbid"`
+	}
+	if err := client.Database("admin").RunCommand(d.ctx, bson.D{{Key: "replSetGetRBID", Value: 1}}).Decode(&rbid); err != nil {
+		logger.Error("cannot get replSetGetRBID", "error", err)
+	} else {
+		rollbacks := d.rollbacks.observe(rbid.RBID)
+		metrics = append(metrics,
+			prometheus.MustNewConstMetric(prometheus.NewDesc("mongodb_rs_rollback_id",
+				"Rollback ID of the member (replSetGetRBID), changed by each rollback", nil, labels),
+				prometheus.GaugeValue, float64(rbid.RBID)),
+			prometheus.MustNewConstMetric(prometheus.NewDesc("mongodb_rs_rollbacks_total",
+				"Number of rollbacks detected since the exporter started", nil, labels),
+				prometheus.CounterValue, rollbacks),
+		)
+	}
+
+	for _, metric := range metrics {
+		ch <- metric
+	}
+}
+
+// electionMetrics makes the term and the metrics of the last elections this member
+// won or voted in.
+func electionMetrics(status *proto.ReplicaSetStatus, labels map[string]string) []prometheus.Metric {
+	gauge := func(name, help string, value float64) prometheus.Metric {
+		desc := prometheus.NewDesc("mongodb_rs_"+name, help, nil, labels)
+		return prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, value)
+	}
+
+	metrics := []prometheus.Metric{
+		gauge("term", "Election count of the replica set, as known by this member", status.Term),
+	}
+
+	if c := status.ElectionCandidateMetrics; c != nil {
+		reasonDesc := prometheus.NewDesc("mongodb_rs_election_candidate_reason_info",
+			"Reason of the last election won by this member", []string{"reason"}, labels)
+		priorityTakeover := 0.0
+		if c.LastElectionReason == electionReasonPriorityTakeover {
+			priorityTakeover = 1
+		}
+
+		metrics = append(metrics,
+			prometheus.MustNewConstMetric(reasonDesc, prometheus.GaugeValue, 1, c.LastElectionReason),
+			gauge("election_candidate_priority_takeover", "1 if the last election won by this member was a priority takeover", priorityTakeover),
+			gauge("election_candidate_term", "Term of the last election won by this member", c.ElectionTerm),
+			gauge("election_candidate_timestamp_seconds", "Date of the last election won by this member", unixSeconds(c.LastElectionDate.Time())),
+			gauge("election_candidate_votes_needed", "Number of votes needed to win the last election", c.NumVotesNeeded),
+			gauge("election_candidate_priority", "Priority of this member at the last election it won", c.PriorityAtElection),
+		)
+
+		// The catch-up is over once the new primary accepts writes.
+		if c.NewTermStartDate != 0 {
+			catchUp := c.NewTermStartDate.Time().Sub(c.LastElectionDate.Time()).Seconds()
+			metrics = append(metrics, gauge("election_candidate_catchup_duration_seconds",
+				"Time between the election of this member and the start of its term, after catching up", catchUp))
+		}
+
+		if c.NumCatchUpOps != nil {
+			metrics = append(metrics, gauge("election_candidate_catchup_ops",
+				"Number of operations applied by this member to catch up after its last election", *c.NumCatchUpOps))
+		}
+	}
+
+	if p := status.ElectionParticipantMetrics; p != nil {
+		voted := 0.0
+		if p.VotedForCandidate {
+			voted = 1
+		}
+
+		metrics = append(metrics,
+			gauge("election_participant_voted_for_candidate", "1 if this member voted for the candidate of the last election it took part in", voted),
+			gauge("election_participant_term", "Term of the last election this member voted in", p.ElectionTerm),
+			gauge("election_participant_timestamp_seconds", "Date of the last vote of this member", unixSeconds(p.LastVoteDate.Time())),
+			gauge("election_participant_candidate_member_id", "Replica set member ID of the candidate of the last election this member voted in", p.ElectionCandidateMemberID),
+		)
+	}
+
+	return metrics
+}
+
+func unixSeconds(t time.Time) float64 {
+	return float64(t.UnixMilli()) / float64(time.Second/time.Millisecond)
+}
+
+var _ prometheus.Collector = (*replicationEventsCollector)(nil)
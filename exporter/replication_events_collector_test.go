@@ -0,0 +1,131 @@
+// mongodb_exporter
+// Copyright (C) 2017 Percona LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/promslog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/percona/mongodb_exporter/internal/proto"
+	"github.com/percona/mongodb_exporter/internal/tu"
+)
+
+func TestReplicationEventsCollector(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	client := tu.DefaultTestClient(ctx, t)
+
+	c := newReplicationEventsCollector(ctx, client, promslog.New(&promslog.Config{}), labelsGetterMock{}, &rollbackTracker{})
+
+	expected := strings.NewReader(`
+	# HELP mongodb_rs_rollbacks_total Number of rollbacks detected since the exporter started
+	# TYPE mongodb_rs_rollbacks_total counter
+	mongodb_rs_rollbacks_total 0` + "\n")
+	assert.NoError(t, testutil.CollectAndCompare(c, expected, "mongodb_rs_rollbacks_total"))
+	assert.Equal(t, 1, testutil.CollectAndCount(c, "mongodb_rs_term"))
+}
+
+func TestRollbackTracker(t *testing.T) {
+	t.Parallel()
+
+	tracker := &rollbackTracker{}
+
+	assert.Zero(t, tracker.observe(1000))
+	assert.Zero(t, tracker.observe(1000))
+	assert.InDelta(t, 2, tracker.observe(1002), 0)
+	// A lower ID, for example after a resync, counts as one rollback.
+	assert.InDelta(t, 3, tracker.observe(7), 0)
+}
+
+func TestElectionMetrics(t *testing.T) {
+	t.Parallel()
+
+	elected := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	catchUpOps := 12.0
+
+	status := &proto.ReplicaSetStatus{
+		Term: 5,
+		ElectionCandidateMetrics: &proto.ElectionCandidateMetrics{
+			LastElectionReason: "priorityTakeover",
+			LastElectionDate:   primitive.NewDateTimeFromTime(elected),
+			ElectionTerm:       5,
+			NumVotesNeeded:     2,
+			PriorityAtElection: 2,
+			NumCatchUpOps:      &catchUpOps,
+			NewTermStartDate:   primitive.NewDateTimeFromTime(elected.Add(1500 * time.Millisecond)),
+		},
+		ElectionParticipantMetrics: &proto.ElectionParticipantMetrics{
+			VotedForCandidate:         true,
+			ElectionTerm:              4,
+			LastVoteDate:              primitive.NewDateTimeFromTime(elected.Add(-time.Hour)),
+			ElectionCandidateMemberID: 2,
+		},
+	}
+
+	expected := strings.NewReader(`
+	# HELP mongodb_rs_election_candidate_catchup_duration_seconds Time between the election of this member and the start of its term, after catching up
+	# TYPE mongodb_rs_election_candidate_catchup_duration_seconds gauge
+	mongodb_rs_election_candidate_catchup_duration_seconds 1.5
+	# HELP mongodb_rs_election_candidate_catchup_ops Number of operations applied by this member to catch up after its last election
+	# TYPE mongodb_rs_election_candidate_catchup_ops gauge
+	mongodb_rs_election_candidate_catchup_ops 12
+	# HELP mongodb_rs_election_candidate_priority Priority of this member at the last election it won
+	# TYPE mongodb_rs_election_candidate_priority gauge
+	mongodb_rs_election_candidate_priority 2
+	# HELP mongodb_rs_election_candidate_priority_takeover 1 if the last election won by this member was a priority takeover
+	# TYPE mongodb_rs_election_candidate_priority_takeover gauge
+	mongodb_rs_election_candidate_priority_takeover 1
+	# HELP mongodb_rs_election_candidate_reason_info Reason of the last election won by this member
+	# TYPE mongodb_rs_election_candidate_reason_info gauge
+	mongodb_rs_election_candidate_reason_info{reason="priorityTakeover"} 1
+	# HELP mongodb_rs_election_candidate_term Term of the last election won by this member
+	# TYPE mongodb_rs_election_candidate_term gauge
+	mongodb_rs_election_candidate_term 5
+	# HELP mongodb_rs_election_candidate_timestamp_seconds Date of the last election won by this member
+	# TYPE mongodb_rs_election_candidate_timestamp_seconds gauge
+	mongodb_rs_election_candidate_timestamp_seconds 1.7672688e+09
+	# HELP mongodb_rs_election_candidate_votes_needed Number of votes needed to win the last election
+	# TYPE mongodb_rs_election_candidate_votes_needed gauge
+	mongodb_rs_election_candidate_votes_needed 2
+	# HELP mongodb_rs_election_participant_candidate_member_id Replica set member ID of the candidate of the last election this member voted in
+	# TYPE mongodb_rs_election_participant_candidate_member_id gauge
+	mongodb_rs_election_participant_candidate_member_id 2
+	# HELP mongodb_rs_election_participant_term Term of the last election this member voted in
+	# TYPE mongodb_rs_election_participant_term gauge
+	mongodb_rs_election_participant_term 4
+	# HELP mongodb_rs_election_participant_timestamp_seconds Date of the last vote of this member
+	# TYPE mongodb_rs_election_participant_timestamp_seconds gauge
+	mongodb_rs_election_participant_timestamp_seconds 1.7672652e+09
+	# HELP mongodb_rs_election_participant_voted_for_candidate 1 if this member voted for the candidate of the last election it took part in
+	# TYPE mongodb_rs_election_participant_voted_for_candidate gauge
+	mongodb_rs_election_participant_voted_for_candidate 1
+	# HELP mongodb_rs_term Election count of the replica set, as known by this member
+	# TYPE mongodb_rs_term gauge
+	mongodb_rs_term 5` + "\n")
+	require.NoError(t, testutil.CollectAndCompare(metricsCollector(electionMetrics(status, nil)), expected))
+
+	// Secondaries which never won an election only report the term.
+	assert.Len(t, electionMetrics(&proto.ReplicaSetStatus{Term: 5}, nil), 1)
+}
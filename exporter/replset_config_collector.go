@@ -17,30 +17,67 @@ package exporter
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/percona/mongodb_exporter/internal/proto"
 )
 
+// configVersionTracker counts the replica set config changes between scrapes.
+type configVersionTracker struct {
+	mu      sync.Mutex
+	version int32
+	term    int64
+	known   bool
+	changes float64
+}
+
+// observe records the current config version and term and returns the number of
+// changes seen since the exporter started. Forced reconfigurations can change the
+// version by any amount, so each observed change counts as one.
+func (t *configVersionTracker) observe(version int32, term int64) float64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.known && (version != t.version || term != t.term) {
+		t.changes++
+	}
+
+	t.version = version
+	t.term = term
+	t.known = true
+
+	return t.changes
+}
+
 type replSetGetConfigCollector struct {
 	ctx  context.Context
 	base *baseCollector
 
 	compatibleMode bool
 	topologyInfo   labelsGetter
+	versions       *configVersionTracker
 }
 
 // newReplicationSetConfigCollector creates a collector for configuration of replication set.
-func newReplicationSetConfigCollector(ctx context.Context, client *mongo.Client, logger *slog.Logger, compatible bool, topology labelsGetter) *replSetGetConfigCollector {
+func newReplicationSetConfigCollector(ctx context.Context, client *mongo.Client, logger *slog.Logger, compatible bool, topology labelsGetter, versions *configVersionTracker) *replSetGetConfigCollector {
 	return &replSetGetConfigCollector{
 		ctx:  ctx,
 		base: newBaseCollector(client, logger.With("collector", "replset_config")),
 
 		compatibleMode: compatible,
 		topologyInfo:   topology,
+		versions:       versions,
 	}
 }
 
@@ -89,6 +126,96 @@ func (d *replSetGetConfigCollector) collect(ch chan<- prometheus.Metric) {
 	for _, metric := range makeMetrics("rs_cfg", m, d.topologyInfo.baseLabels(), d.compatibleMode) {
 		ch <- metric
 	}
+
+	var rs proto.ReplicasetConfig
+	if err := res.Decode(&rs); err != nil {
+		logger.Error("cannot decode replSetGetConfig", "error", err)
+		return
+	}
+
+	for _, metric := range replSetConfigMetrics(&rs.Config, d.versions, d.topologyInfo.baseLabels()) {
+		ch <- metric
+	}
+}
+
+// replSetConfigMetrics makes typed metrics from the replica set config: one info
+// series per member with its attributes, the settings, the version and the number
+// of config changes counted by versions.
+func replSetConfigMetrics(config *proto.RSConfig, versions *configVersionTracker, labels map[string]string) []prometheus.Metric {
+	gauge := func(name, help string, value float64) prometheus.Metric {
+		desc := prometheus.NewDesc("mongodb_rs_config_"+name, help, nil, labels)
+		return prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, value)
+	}
+	toFloat := func(b bool) float64 {
+		if b {
+			return 1
+		}
+
+		return 0
+	}
+	millisToSeconds := func(ms int32) float64 {
+		return (time.Duration(ms) * time.Millisecond).Seconds()
+	}
+
+	memberDesc := prometheus.NewDesc("mongodb_rs_config_member_info", "Configuration of a replica set member",
+		[]string{"member", "member_id", "votes", "priority", "hidden", "secondary_delay_secs", "build_indexes", "arbiter", "tags"}, labels)
+
+	metrics := []prometheus.Metric{
+		gauge("version", "Version of the replica set config", float64(config.Version)),
+		gauge("term", "Term of the replica set config (4.4+)", float64(config.Term)),
+		gauge("members", "Number of members in the replica set config", float64(len(config.Members))),
+		gauge("write_concern_majority_journal_default", "1 if w:majority writes are acknowledged once written to the journal of the majority",
+			toFloat(config.WriteConcernMajorityJournalDefault)),
+		gauge("chaining_allowed", "1 if secondaries can replicate from other secondaries", toFloat(config.Settings.ChainingAllowed)),
+		gauge("election_timeout_seconds", "Time without heartbeats from the primary before an election is called",
+			millisToSeconds(config.Settings.ElectionTimeoutMillis)),
+		gauge("heartbeat_timeout_seconds", "Time after which an unanswered heartbeat marks a member as unreachable",
+			float64(config.Settings.HeartbeatTimeoutSecs)),
+	}
+
+	if versions != nil {
+		desc := prometheus.NewDesc("mongodb_rs_config_version_changes_total",
+			"Number of replica set config changes (version or term) detected since the exporter started", nil, labels)
+		metrics = append(metrics, prometheus.MustNewConstMetric(desc, prometheus.CounterValue, versions.observe(config.Version, config.Term)))
+	}
+
+	// Removed in 5.0.
+	if gle := config.Settings.GetLastErrorDefaults; gle.W != nil {
+		desc := prometheus.NewDesc("mongodb_rs_config_get_last_error_defaults_info",
+			"Default write concern of the replica set (getLastErrorDefaults)", []string{"w"}, labels)
+		metrics = append(metrics,
+			prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, 1, fmt.Sprint(gle.W)),
+			gauge("get_last_error_defaults_wtimeout_seconds", "Default write concern timeout of the replica set (0 = no timeout)",
+				millisToSeconds(gle.WTimeout)),
+		)
+	}
+
+	for _, m := range config.Members {
+		metrics = append(metrics, prometheus.MustNewConstMetric(memberDesc, prometheus.GaugeValue, 1,
+			m.Host,
+			strconv.Itoa(int(m.ID)),
+			strconv.Itoa(int(m.Votes)),
+			strconv.FormatFloat(m.Priority, 'f', -1, 64),
+			strconv.FormatBool(m.Hidden),
+			strconv.FormatInt(memberDelaySecs(m), 10),
+			strconv.FormatBool(m.BuildIndexes),
+			strconv.FormatBool(m.ArbiterOnly),
+			formatTags(m.Tags),
+		))
+	}
+
+	return metrics
+}
+
+// formatTags formats the member tags as a sorted list of key=value pairs.
+func formatTags(tags bson.M) string {
+	pairs := make([]string, 0, len(tags))
+	for k, v := range tags {
+		pairs = append(pairs, fmt.Sprintf("%s=%v", k, v))
+	}
+	sort.Strings(pairs)
+
+	return strings.Join(pairs, ",")
 }
 
 var _ prometheus.Collector = (*replSetGetConfigCollector)(nil)
@@ -24,7 +24,10 @@ import (
 	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/prometheus/common/promslog"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
 
+	"github.com/percona/mongodb_exporter/internal/proto"
 	"github.com/percona/mongodb_exporter/internal/tu"
 )
 
@@ -36,7 +39,7 @@ func TestReplsetConfigCollector(t *testing.T) {
 
 	ti := labelsGetterMock{}
 
-	c := newReplicationSetConfigCollector(ctx, client, promslog.New(&promslog.Config{}), false, ti)
+	c := newReplicationSetConfigCollector(ctx, client, promslog.New(&promslog.Config{}), false, ti, &configVersionTracker{})
 
 	// The last \n at the end of this string is important
 	expected := strings.NewReader(`
@@ -62,7 +65,7 @@ func TestReplsetConfigCollectorNoSharding(t *testing.T) {
 
 	ti := labelsGetterMock{}
 
-	c := newReplicationSetConfigCollector(ctx, client, promslog.New(&promslog.Config{}), false, ti)
+	c := newReplicationSetConfigCollector(ctx, client, promslog.New(&promslog.Config{}), false, ti, &configVersionTracker{})
 
 	// Replication set metrics should not be generated for unsharded server
 	count := testutil.CollectAndCount(c)
@@ -70,3 +73,67 @@ func TestReplsetConfigCollectorNoSharding(t *testing.T) {
 	metaMetricCount := 1
 	assert.Equal(t, metaMetricCount, count, "Mismatch in metric count for collector run on unsharded server")
 }
+
+func TestReplSetConfigMetrics(t *testing.T) {
+	t.Parallel()
+
+	config := &proto.RSConfig{
+		Version:                            3,
+		Term:                               2,
+		WriteConcernMajorityJournalDefault: true,
+		Settings: proto.RSSettings{
+			HeartbeatTimeoutSecs:  10,
+			ElectionTimeoutMillis: 10000,
+			ChainingAllowed:       true,
+			GetLastErrorDefaults:  proto.LastErrorDefaults{W: "majority", WTimeout: 5000},
+		},
+		Members: []proto.Member{
+			{Host: "rs1:27017", ID: 0, Votes: 1, Priority: 1.5, BuildIndexes: true, Tags: bson.M{"rack": "a", "dc": "east"}},
+			{Host: "rs2:27017", ID: 1, Votes: 0, Hidden: true, SlaveDelay: 3600, BuildIndexes: true},
+			{Host: "rs3:27017", ID: 2, Votes: 1, ArbiterOnly: true},
+		},
+	}
+
+	versions := &configVersionTracker{}
+	replSetConfigMetrics(config, versions, nil)
+
+	// A reconfig with a new version is counted once.
+	config.Version = 5
+	expected := strings.NewReader(`
+	# HELP mongodb_rs_config_chaining_allowed 1 if secondaries can replicate from other secondaries
+	# TYPE mongodb_rs_config_chaining_allowed gauge
+	mongodb_rs_config_chaining_allowed 1
+	# HELP mongodb_rs_config_election_timeout_seconds Time without heartbeats from the primary before an election is called
+	# TYPE mongodb_rs_config_election_timeout_seconds gauge
+	mongodb_rs_config_election_timeout_seconds 10
+	# HELP mongodb_rs_config_get_last_error_defaults_info Default write concern of the replica set (getLastErrorDefaults)
+	# TYPE mongodb_rs_config_get_last_error_defaults_info gauge
+	mongodb_rs_config_get_last_error_defaults_info{w="majority"} 1
+	# HELP mongodb_rs_config_get_last_error_defaults_wtimeout_seconds Default write concern timeout of the replica set (0 = no timeout)
+	# TYPE mongodb_rs_config_get_last_error_defaults_wtimeout_seconds gauge
+	mongodb_rs_config_get_last_error_defaults_wtimeout_seconds 5
+	# HELP mongodb_rs_config_heartbeat_timeout_seconds Time after which an unanswered heartbeat marks a member as unreachable
+	# TYPE mongodb_rs_config_heartbeat_timeout_seconds gauge
+	mongodb_rs_config_heartbeat_timeout_seconds 10
+	# HELP mongodb_rs_config_member_info Configuration of a replica set member
+	# TYPE mongodb_rs_config_member_info gauge
+	mongodb_rs_config_member_info{arbiter="true",build_indexes="false",hidden="false",member="rs3:27017",member_id="2",priority="0",secondary_delay_secs="0",tags="",votes="1"} 1
+	mongodb_rs_config_member_info{arbiter="false",build_indexes="true",hidden="false",member="rs1:27017",member_id="0",priority="1.5",secondary_delay_secs="0",tags="dc=east,rack=a",votes="1"} 1
+	mongodb_rs_config_member_info{arbiter="false",build_indexes="true",hidden="true",member="rs2:27017",member_id="1",priority="0",secondary_delay_secs="3600",tags="",votes="0"} 1
+	# HELP mongodb_rs_config_members Number of members in the replica set config
+	# TYPE mongodb_rs_config_members gauge
+	mongodb_rs_config_members 3
+	# HELP mongodb_rs_config_term Term of the replica set config (4.4+)
+	# TYPE mongodb_rs_config_term gauge
+	mongodb_rs_config_term 2
+	# HELP mongodb_rs_config_version Version of the replica set config
+	# TYPE mongodb_rs_config_version gauge
+	mongodb_rs_config_version 5
+	# HELP mongodb_rs_config_version_changes_total Number of replica set config changes (version or term) detected since the exporter started
+	# TYPE mongodb_rs_config_version_changes_total counter
+	mongodb_rs_config_version_changes_total 1
+	# HELP mongodb_rs_config_write_concern_majority_journal_default 1 if w:majority writes are acknowledged once written to the journal of the majority
+	# TYPE mongodb_rs_config_write_concern_majority_journal_default gauge
+	mongodb_rs_config_write_concern_majority_journal_default 1` + "\n")
+	require.NoError(t, testutil.CollectAndCompare(metricsCollector(replSetConfigMetrics(config, versions, nil)), expected))
+}
@@ -18,15 +18,23 @@ package exporter
 import (
 	"context"
 	"log/slog"
+	"strings"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
 	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/percona/mongodb_exporter/internal/proto"
 )
 
 const (
 	replicationNotEnabled        = 76
 	replicationNotYetInitialized = 94
+
+	// startup2State is the state of a member doing an initial sync.
+	startup2State = 5
 )
 
 type replSetGetStatusCollector struct {
@@ -84,6 +92,131 @@ func (d *replSetGetStatusCollector) collect(ch chan<- prometheus.Metric) {
 	for _, metric := range makeMetrics("", m, d.topologyInfo.baseLabels(), d.compatibleMode) {
 		ch <- metric
 	}
+
+	if state, err := asFloat64(m["myState"]); err == nil && state != nil && *state == startup2State {
+		d.collectInitialSync(ch)
+	}
+}
+
+// collectInitialSync sends the progress of the initial sync of this member.
+func (d *replSetGetStatusCollector) collectInitialSync(ch chan<- prometheus.Metric) {
+	logger := d.base.logger
+
+	var status proto.ReplicaSetStatus
+	cmd := bson.D{{Key: "replSetGetStatus", Value: 1}, {Key: "initialSync", Value: 1}}
+	if err := d.base.client.Database("admin").RunCommand(d.ctx, cmd).Decode(&status); err != nil {
+		logger.Error("cannot get initial sync status", "error", err)
+		return
+	}
+
+	for _, metric := range initialSyncMetrics(&status, d.topologyInfo.baseLabels()) {
+		ch <- metric
+	}
+}
+
+// initialSyncMetrics makes the initial sync progress metrics. Bytes are only
+// reported since MongoDB 4.4.
+func initialSyncMetrics(status *proto.ReplicaSetStatus, labels map[string]string) []prometheus.Metric {
+	is := status.InitialSyncStatus
+	if is == nil {
+		return nil
+	}
+
+	gauge := func(name, help string, value float64) prometheus.Metric {
+		desc := prometheus.NewDesc("mongodb_rs_initial_sync_"+name, help, nil, labels)
+		return prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, value)
+	}
+
+	elapsed := (time.Duration(is.TotalInitialSyncElapsedMillis) * time.Millisecond).Seconds()
+
+	metrics := []prometheus.Metric{
+		gauge("elapsed_seconds", "Time since the start of the initial sync, including the failed attempts", elapsed),
+		gauge("failed_attempts", "Number of failed initial sync attempts", is.FailedInitialSyncAttempts),
+		gauge("max_failed_attempts", "Number of failed attempts before the initial sync is aborted", is.MaxFailedInitialSyncAttempts),
+		gauge("copied_bytes", "Approximate number of bytes copied by the initial sync", is.ApproxTotalBytesCopied),
+		gauge("total_bytes", "Approximate number of bytes to copy by the initial sync", is.ApproxTotalDataSize),
+	}
+
+	if status.SyncSourceHost != "" {
+		desc := prometheus.NewDesc("mongodb_rs_initial_sync_source_info", "Member the initial sync copies the data from",
+			[]string{"sync_source"}, labels)
+		metrics = append(metrics, prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, 1, status.SyncSourceHost))
+	}
+
+	if eta, ok := initialSyncETA(is, status.Date.Time()); ok {
+		metrics = append(metrics, gauge("remaining_seconds", "Estimated time until the end of the data copy", eta))
+	}
+
+	copiedDesc := prometheus.NewDesc("mongodb_rs_initial_sync_collection_copied_bytes",
+		"Approximate number of bytes of the collection copied by the initial sync", []string{"database", "collection"}, labels)
+	totalDesc := prometheus.NewDesc("mongodb_rs_initial_sync_collection_total_bytes",
+		"Approximate number of bytes of the collection to copy by the initial sync", []string{"database", "collection"}, labels)
+
+	for ns, coll := range initialSyncCollections(is.Databases) {
+		db, collection := splitNamespace(ns)
+		metrics = append(metrics,
+			prometheus.MustNewConstMetric(copiedDesc, prometheus.GaugeValue, coll.ApproxBytesCopied, db, collection),
+			prometheus.MustNewConstMetric(totalDesc, prometheus.GaugeValue, coll.BytesToCopy, db, collection),
+		)
+	}
+
+	return metrics
+}
+
+// initialSyncETA returns the estimated remaining time of the data copy, as reported
+// by the server or extrapolated from the bytes copied so far.
+func initialSyncETA(is *proto.InitialSyncStatus, now time.Time) (float64, bool) {
+	if is.RemainingInitialSyncEstimatedMillis != nil {
+		return (time.Duration(*is.RemainingInitialSyncEstimatedMillis) * time.Millisecond).Seconds(), true
+	}
+
+	if is.ApproxTotalBytesCopied <= 0 || is.ApproxTotalDataSize <= 0 || is.InitialSyncStart == 0 {
+		return 0, false
+	}
+
+	// Extrapolated from the average copy rate since the start of the initial sync.
+	elapsed := now.Sub(is.InitialSyncStart.Time()).Seconds()
+	remaining := is.ApproxTotalDataSize - is.ApproxTotalBytesCopied
+
+	return max(remaining, 0) * elapsed / is.ApproxTotalBytesCopied, true
+}
+
+// initialSyncCollections returns the progress of the collections by namespace from
+// the databases section of the initial sync status.
+func initialSyncCollections(databases bson.Raw) map[string]proto.InitialSyncCollection {
+	collections := make(map[string]proto.InitialSyncCollection)
+
+	dbs, err := databases.Elements()
+	if err != nil {
+		return collections
+	}
+
+	for _, db := range dbs {
+		// Skip the databasesToClone and databasesCloned counters.
+		dbDoc, ok := db.Value().DocumentOK()
+		if !ok {
+			continue
+		}
+
+		colls, err := dbDoc.Elements()
+		if err != nil {
+			continue
+		}
+
+		for _, coll := range colls {
+			// Collections are the sub documents keyed by namespace.
+			if coll.Value().Type != bsontype.EmbeddedDocument || !strings.Contains(coll.Key(), ".") {
+				continue
+			}
+
+			var progress proto.InitialSyncCollection
+			if err := coll.Value().Unmarshal(&progress); err == nil {
+				collections[coll.Key()] = progress
+			}
+		}
+	}
+
+	return collections
 }
 
 var _ prometheus.Collector = (*replSetGetStatusCollector)(nil)
@@ -24,7 +24,11 @@ import (
 	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/prometheus/common/promslog"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 
+	"github.com/percona/mongodb_exporter/internal/proto"
 	"github.com/percona/mongodb_exporter/internal/tu"
 )
 
@@ -74,3 +78,88 @@ func TestReplsetStatusCollectorNoSharding(t *testing.T) {
 	metaMetricCount := 1
 	assert.Equal(t, metaMetricCount, count, "Mismatch in metric count for collector run on unsharded server")
 }
+
+func TestInitialSyncMetrics(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	databases, err := bson.Marshal(bson.D{
+		{Key: "databasesToClone", Value: 1},
+		{Key: "databasesCloned", Value: 1},
+		{Key: "admin", Value: bson.D{
+			{Key: "collections", Value: 1},
+			{Key: "clonedCollections", Value: 1},
+			{Key: "admin.system.version", Value: bson.D{
+				{Key: "documentsToCopy", Value: 2},
+				{Key: "documentsCopied", Value: 2},
+				{Key: "bytesToCopy", Value: 100},
+				{Key: "approxBytesCopied", Value: 100},
+			}},
+		}},
+		{Key: "app", Value: bson.D{
+			{Key: "collections", Value: 1},
+			{Key: "clonedCollections", Value: 0},
+			{Key: "app.orders", Value: bson.D{
+				{Key: "bytesToCopy", Value: int64(4000)},
+				{Key: "approxBytesCopied", Value: int64(900)},
+			}},
+		}},
+	})
+	require.NoError(t, err)
+
+	status := &proto.ReplicaSetStatus{
+		Date:           primitive.NewDateTimeFromTime(now),
+		SyncSourceHost: "mongo-1:27017",
+		InitialSyncStatus: &proto.InitialSyncStatus{
+			FailedInitialSyncAttempts:     1,
+			MaxFailedInitialSyncAttempts:  10,
+			InitialSyncStart:              primitive.NewDateTimeFromTime(now.Add(-100 * time.Second)),
+			TotalInitialSyncElapsedMillis: 100000,
+			ApproxTotalDataSize:           4100,
+			ApproxTotalBytesCopied:        1000,
+			Databases:                     databases,
+		},
+	}
+
+	expected := strings.NewReader(`
+	# HELP mongodb_rs_initial_sync_collection_copied_bytes Approximate number of bytes of the collection copied by the initial sync
+	# TYPE mongodb_rs_initial_sync_collection_copied_bytes gauge
+	mongodb_rs_initial_sync_collection_copied_bytes{collection="orders",database="app"} 900
+	mongodb_rs_initial_sync_collection_copied_bytes{collection="system.version",database="admin"} 100
+	# HELP mongodb_rs_initial_sync_collection_total_bytes Approximate number of bytes of the collection to copy by the initial sync
+	# TYPE mongodb_rs_initial_sync_collection_total_bytes gauge
+	mongodb_rs_initial_sync_collection_total_bytes{collection="orders",database="app"} 4000
+	mongodb_rs_initial_sync_collection_total_bytes{collection="system.version",database="admin"} 100
+	# HELP mongodb_rs_initial_sync_copied_bytes Approximate number of bytes copied by the initial sync
+	# TYPE mongodb_rs_initial_sync_copied_bytes gauge
+	mongodb_rs_initial_sync_copied_bytes 1000
+	# HELP mongodb_rs_initial_sync_elapsed_seconds Time since the start of the initial sync, including the failed attempts
+	# TYPE mongodb_rs_initial_sync_elapsed_seconds gauge
+	mongodb_rs_initial_sync_elapsed_seconds 100
+	# HELP mongodb_rs_initial_sync_failed_attempts Number of failed initial sync attempts
+	# TYPE mongodb_rs_initial_sync_failed_attempts gauge
+	mongodb_rs_initial_sync_failed_attempts 1
+	# HELP mongodb_rs_initial_sync_max_failed_attempts Number of failed attempts before the initial sync is aborted
+	# TYPE mongodb_rs_initial_sync_max_failed_attempts gauge
+	mongodb_rs_initial_sync_max_failed_attempts 10
+	# HELP mongodb_rs_initial_sync_remaining_seconds Estimated time until the end of the data copy
+	# TYPE mongodb_rs_initial_sync_remaining_seconds gauge
+	mongodb_rs_initial_sync_remaining_seconds 310
+	# HELP mongodb_rs_initial_sync_source_info Member the initial sync copies the data from
+	# TYPE mongodb_rs_initial_sync_source_info gauge
+	mongodb_rs_initial_sync_source_info{sync_source="mongo-1:27017"} 1
+	# HELP mongodb_rs_initial_sync_total_bytes Approximate number of bytes to copy by the initial sync
+	# TYPE mongodb_rs_initial_sync_total_bytes gauge
+	mongodb_rs_initial_sync_total_bytes 4100` + "\n")
+	require.NoError(t, testutil.CollectAndCompare(metricsCollector(initialSyncMetrics(status, nil)), expected))
+
+	// The estimation of the server takes precedence.
+	remaining := 42000.0
+	status.InitialSyncStatus.RemainingInitialSyncEstimatedMillis = &remaining
+	eta, ok := initialSyncETA(status.InitialSyncStatus, now)
+	assert.True(t, ok)
+	assert.InDelta(t, 42, eta, 0)
+
+	assert.Empty(t, initialSyncMetrics(&proto.ReplicaSetStatus{}, nil))
+}
@@ -0,0 +1,201 @@
+// mongodb_exporter
+// Copyright (C) 2017 Percona LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// reshardingField is a numeric field of the $currentOp output of a resharding
+// operation, reported when the operation has it.
+type reshardingField struct {
+	field string
+	name  string
+	help  string
+}
+
+var reshardingFields = []reshardingField{
+	{"totalOperationTimeElapsedSecs", "elapsed_seconds", "Time elapsed since the start of the resharding operation"},
+	{"remainingOperationTimeEstimatedSecs", "remaining_estimated_seconds", "Estimated time left before the resharding operation completes"},
+	{"documentsCopied", "documents_copied", "Number of documents copied to the recipient shard"},
+	{"approxDocumentsToCopy", "documents_to_copy", "Approximate number of documents to copy to the recipient shard"},
+	{"bytesCopied", "copied_bytes", "Size of the documents copied to the recipient shard"},
+	{"approxBytesToCopy", "to_copy_bytes", "Approximate size of the documents to copy to the recipient shard"},
+	{"oplogEntriesFetched", "oplog_entries_fetched", "Number of oplog entries fetched from the donor shards"},
+	{"oplogEntriesApplied", "oplog_entries_applied", "Number of oplog entries applied on the recipient shard"},
+	{"totalCriticalSectionTimeElapsedSecs", "critical_section_elapsed_seconds", "Time elapsed since the start of the critical section, when writes are blocked"},
+}
+
+type reshardingCollector struct {
+	ctx  context.Context
+	base *baseCollector
+
+	topologyInfo labelsGetter
+}
+
+// newReshardingCollector creates a collector for the progress of the resharding
+// operations of a sharded cluster, which also run reshardCollection, moveCollection
+// and unshardCollection.
+func newReshardingCollector(ctx context.Context, client *mongo.Client, logger *slog.Logger, topology labelsGetter) *reshardingCollector {
+	return &reshardingCollector{
+		ctx:  ctx,
+		base: newBaseCollector(client, logger.With("collector", "resharding")),
+
+		topologyInfo: topology,
+	}
+}
+
+func (d *reshardingCollector) Describe(ch chan<- *prometheus.Desc) {
+	d.base.Describe(d.ctx, ch, d.collect)
+}
+
+func (d *reshardingCollector) Collect(ch chan<- prometheus.Metric) {
+	d.base.Collect(ch)
+}
+
+func (d *reshardingCollector) collect(ch chan<- prometheus.Metric) {
+	defer measureCollectTime(ch, "mongodb", "resharding")()
+
+	logger := d.base.logger
+	client := d.base.client
+	if client == nil {
+		return
+	}
+
+	ops, err := reshardingOperations(d.ctx, client)
+	if err != nil {
+		logger.Error("cannot get the resharding operations", "error", err)
+		return
+	}
+
+	for _, metric := range reshardingMetrics(ops, d.topologyInfo.baseLabels()) {
+		ch <- metric
+	}
+}
+
+// reshardingOperations returns the coordinator, donor and recipient operations of
+// the resharding in progress, from the shards and config servers.
+func reshardingOperations(ctx context.Context, client *mongo.Client) ([]bson.M, error) {
+	match := bson.A{}
+	for _, r := range reshardingRoles {
+		match = append(match, bson.M{r.stateField: bson.M{"$exists": true}})
+	}
+
+	pipeline := bson.A{
+		bson.M{"$currentOp": bson.M{"allUsers": true, "localOps": false}},
+		bson.M{"$match": bson.M{"type": "op", "$or": match}},
+	}
+
+	cursor, err := client.Database("admin").Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+
+	var ops []bson.M
+	if err := cursor.All(ctx, &ops); err != nil {
+		return nil, fmt.Errorf("cannot decode $currentOp: %w", err)
+	}
+
+	return ops, nil
+}
+
+// reshardingRole is a role in a resharding operation, found from the state field
+// of its $currentOp output.
+type reshardingRole struct {
+	role       string
+	stateField string
+}
+
+var reshardingRoles = []reshardingRole{
+	{"coordinator", "coordinatorState"},
+	{"donor", "donorState"},
+	{"recipient", "recipientState"},
+}
+
+// reshardingState returns the role of a resharding operation and its state.
+func reshardingState(op bson.M) (string, string) {
+	for _, r := range reshardingRoles {
+		if state, ok := op[r.stateField].(string); ok {
+			return r.role, state
+		}
+	}
+
+	return "", ""
+}
+
+// reshardingOperation returns the command that started a resharding operation. The
+// provenance field is reported since 8.0, when moveCollection and unshardCollection
+// were added, so operations without it come from reshardCollection.
+func reshardingOperation(op bson.M) string {
+	if provenance, ok := op["provenance"].(string); ok && provenance != "" {
+		return provenance
+	}
+
+	return "reshardCollection"
+}
+
+// reshardingMetrics makes the progress metrics of the resharding operations, labelled
+// with the namespace, the shard, the role and the command of the operation.
+func reshardingMetrics(ops []bson.M, labels map[string]string) []prometheus.Metric {
+	labelNames := []string{"ns", "shard", "role", "operation"}
+	stateDesc := prometheus.NewDesc("mongodb_resharding_state_info",
+		"State of the resharding operation", append(labelNames, "state"), labels)
+	toApplyDesc := prometheus.NewDesc("mongodb_resharding_oplog_entries_to_apply",
+		"Number of oplog entries fetched from the donor shards and not applied yet on the recipient shard", labelNames, labels)
+
+	var metrics []prometheus.Metric
+	for _, op := range ops {
+		role, state := reshardingState(op)
+		if role == "" {
+			continue
+		}
+
+		ns, _ := op["ns"].(string)
+		shard, _ := op["shard"].(string)
+		operation := reshardingOperation(op)
+		values := []string{ns, shard, role, operation}
+
+		metrics = append(metrics, prometheus.MustNewConstMetric(stateDesc, prometheus.GaugeValue, 1, ns, shard, role, operation, state))
+
+		found := make(map[string]float64)
+		for _, f := range reshardingFields {
+			v, err := asFloat64(op[f.field])
+			if err != nil || v == nil {
+				continue
+			}
+			found[f.field] = *v
+
+			d := prometheus.NewDesc("mongodb_resharding_"+f.name, f.help, labelNames, labels)
+			metrics = append(metrics, prometheus.MustNewConstMetric(d, prometheus.GaugeValue, *v, values...))
+		}
+
+		fetched, okFetched := found["oplogEntriesFetched"]
+		applied, okApplied := found["oplogEntriesApplied"]
+		if okFetched && okApplied {
+			metrics = append(metrics, prometheus.MustNewConstMetric(toApplyDesc, prometheus.GaugeValue, max(0, fetched-applied), values...))
+		}
+	}
+
+	return metrics
+}
+
+var _ prometheus.Collector = (*reshardingCollector)(nil)
@@ -0,0 +1,106 @@
+// mongodb_exporter
+// Copyright (C) 2017 Percona LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestReshardingMetrics(t *testing.T) {
+	t.Parallel()
+
+	ops := []bson.M{
+		{
+			"shard":                         "config",
+			"desc":                          "ReshardingCoordinator 0b5d9ea7-7c44-4b8a-9e0f-05a8c1a6a7f2",
+			"provenance":                    "moveCollection",
+			"ns":                            "test.users",
+			"coordinatorState":              "blocking-writes",
+			"totalOperationTimeElapsedSecs": int64(3600),
+			// Coordinators report it since 7.0.
+			"totalCriticalSectionTimeElapsedSecs": int64(2),
+		},
+		{
+			"shard":                               "rs2",
+			"desc":                                "ReshardingRecipientService 0b5d9ea7-7c44-4b8a-9e0f-05a8c1a6a7f2",
+			"ns":                                  "test.users",
+			"recipientState":                      "applying",
+			"totalOperationTimeElapsedSecs":       int64(3590),
+			"remainingOperationTimeEstimatedSecs": int64(60),
+			"documentsCopied":                     int64(1000),
+			"approxDocumentsToCopy":               int64(1000),
+			"bytesCopied":                         int64(4096),
+			"approxBytesToCopy":                   int64(4000),
+			"oplogEntriesFetched":                 int64(50),
+			"oplogEntriesApplied":                 int64(40),
+		},
+		// Reported before 8.0, without provenance.
+		{
+			"shard":                         "rs1",
+			"desc":                          "ReshardingDonorService 0b5d9ea7-7c44-4b8a-9e0f-05a8c1a6a7f2",
+			"ns":                            "test.users",
+			"donorState":                    "donating-oplog-entries",
+			"totalOperationTimeElapsedSecs": int64(3595),
+		},
+		// Not a resharding operation.
+		{"shard": "rs1", "desc": "ReshardingMetricsService", "ns": "test.users"},
+	}
+
+	expected := strings.NewReader(`
+	# HELP mongodb_resharding_copied_bytes Size of the documents copied to the recipient shard
+	# TYPE mongodb_resharding_copied_bytes gauge
+	mongodb_resharding_copied_bytes{ns="test.users",operation="reshardCollection",role="recipient",shard="rs2"} 4096
+	# HELP mongodb_resharding_critical_section_elapsed_seconds Time elapsed since the start of the critical section, when writes are blocked
+	# TYPE mongodb_resharding_critical_section_elapsed_seconds gauge
+	mongodb_resharding_critical_section_elapsed_seconds{ns="test.users",operation="moveCollection",role="coordinator",shard="config"} 2
+	# HELP mongodb_resharding_documents_copied Number of documents copied to the recipient shard
+	# TYPE mongodb_resharding_documents_copied gauge
+	mongodb_resharding_documents_copied{ns="test.users",operation="reshardCollection",role="recipient",shard="rs2"} 1000
+	# HELP mongodb_resharding_documents_to_copy Approximate number of documents to copy to the recipient shard
+	# TYPE mongodb_resharding_documents_to_copy gauge
+	mongodb_resharding_documents_to_copy{ns="test.users",operation="reshardCollection",role="recipient",shard="rs2"} 1000
+	# HELP mongodb_resharding_elapsed_seconds Time elapsed since the start of the resharding operation
+	# TYPE mongodb_resharding_elapsed_seconds gauge
+	mongodb_resharding_elapsed_seconds{ns="test.users",operation="moveCollection",role="coordinator",shard="config"} 3600
+	mongodb_resharding_elapsed_seconds{ns="test.users",operation="reshardCollection",role="donor",shard="rs1"} 3595
+	mongodb_resharding_elapsed_seconds{ns="test.users",operation="reshardCollection",role="recipient",shard="rs2"} 3590
+	# HELP mongodb_resharding_oplog_entries_applied Number of oplog entries applied on the recipient shard
+	# TYPE mongodb_resharding_oplog_entries_applied gauge
+	mongodb_resharding_oplog_entries_applied{ns="test.users",operation="reshardCollection",role="recipient",shard="rs2"} 40
+	# HELP mongodb_resharding_oplog_entries_fetched Number of oplog entries fetched from the donor shards
+	# TYPE mongodb_resharding_oplog_entries_fetched gauge
+	mongodb_resharding_oplog_entries_fetched{ns="test.users",operation="reshardCollection",role="recipient",shard="rs2"} 50
+	# HELP mongodb_resharding_oplog_entries_to_apply Number of oplog entries fetched from the donor shards and not applied yet on the recipient shard
+	# TYPE mongodb_resharding_oplog_entries_to_apply gauge
+	mongodb_resharding_oplog_entries_to_apply{ns="test.users",operation="reshardCollection",role="recipient",shard="rs2"} 10
+	# HELP mongodb_resharding_remaining_estimated_seconds Estimated time left before the resharding operation completes
+	# TYPE mongodb_resharding_remaining_estimated_seconds gauge
+	mongodb_resharding_remaining_estimated_seconds{ns="test.users",operation="reshardCollection",role="recipient",shard="rs2"} 60
+	# HELP mongodb_resharding_state_info State of the resharding operation
+	# TYPE mongodb_resharding_state_info gauge
+	mongodb_resharding_state_info{ns="test.users",operation="moveCollection",role="coordinator",shard="config",state="blocking-writes"} 1
+	mongodb_resharding_state_info{ns="test.users",operation="reshardCollection",role="donor",shard="rs1",state="donating-oplog-entries"} 1
+	mongodb_resharding_state_info{ns="test.users",operation="reshardCollection",role="recipient",shard="rs2",state="applying"} 1
+	# HELP mongodb_resharding_to_copy_bytes Approximate size of the documents to copy to the recipient shard
+	# TYPE mongodb_resharding_to_copy_bytes gauge
+	mongodb_resharding_to_copy_bytes{ns="test.users",operation="reshardCollection",role="recipient",shard="rs2"} 4000` + "\n")
+	require.NoError(t, testutil.CollectAndCompare(metricsCollector(reshardingMetrics(ops, nil)), expected))
+}
@@ -16,30 +16,50 @@
 package exporter
 
 import (
-	"log"
+	"context"
 	"log/slog"
 	"net"
 	"net/url"
 	"strconv"
 	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/pkg/errors"
 )
 
+const resolvConfPath = "/etc/resolv.conf"
+
+var errNoSRVRecords = errors.New("no SRV records found")
+
 // GetSeedListFromSRV converts mongodb+srv URI to flat connection string.
-func GetSeedListFromSRV(uri string, logger *slog.Logger) string { //nolint:cyclop
+// If the SRV records cannot be resolved, the error is logged and the URI is returned unchanged.
+func GetSeedListFromSRV(uri string, logger *slog.Logger) string {
+	seedList, err := ResolveSeedList(uri, logger)
+	if err != nil {
+		logger.Error("Failed to resolve seed list", "uri", uri, "error", err)
+		return uri
+	}
+
+	return seedList
+}
+
+// ResolveSeedList converts mongodb+srv URI to flat connection string using the SRV
+// and TXT records of the host. Only problems with the TXT record are logged, any
+// other resolution error is returned.
+func ResolveSeedList(uri string, logger *slog.Logger) (string, error) { //nolint:cyclop
 	uriParsed, err := url.Parse(uri)
 	if err != nil {
-		log.Fatalf("Failed to parse URI %s: %v", uri, err)
+		return "", errors.Wrap(err, "failed to parse URI")
 	}
 
 	cname, srvRecords, err := net.LookupSRV("mongodb", "tcp", uriParsed.Hostname())
 	if err != nil {
-		logger.Error("Failed to lookup SRV records", "uri", uri, "error", err)
-		return uri
+		return "", errors.Wrap(err, "failed to lookup SRV records")
 	}
 
 	if len(srvRecords) == 0 {
-		logger.Error("No SRV records found", "uri", uri)
-		return uri
+		return "", errNoSRVRecords
 	}
 
 	queryString := uriParsed.RawQuery
@@ -84,5 +104,60 @@ func GetSeedListFromSRV(uri string, logger *slog.Logger) string { //nolint:cyclo
 		uri += "?" + queryString
 	}
 
-	return uri
+	return uri, nil
+}
+
+// SeedListTTL returns the lowest TTL of the SRV and TXT records of a mongodb+srv URI.
+// The standard resolver doesn't expose TTLs so the name servers from resolv.conf
+// are queried directly.
+func SeedListTTL(ctx context.Context, uri string) (time.Duration, error) {
+	uriParsed, err := url.Parse(uri)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to parse URI")
+	}
+
+	conf, err := dns.ClientConfigFromFile(resolvConfPath)
+	if err != nil {
+		return 0, errors.Wrap(err, "cannot read resolver configuration")
+	}
+
+	host := dns.Fqdn(uriParsed.Hostname())
+	queries := map[string]uint16{
+		"_mongodb._tcp." + host: dns.TypeSRV,
+		host:                    dns.TypeTXT,
+	}
+
+	var ttl uint32
+	var lastErr error
+
+	client := &dns.Client{Timeout: time.Duration(conf.Timeout) * time.Second}
+	for name, qtype := range queries {
+		msg := new(dns.Msg).SetQuestion(name, qtype)
+
+		for _, server := range conf.Servers {
+			resp, _, err := client.ExchangeContext(ctx, msg, net.JoinHostPort(server, conf.Port))
+			if err != nil {
+				lastErr = err
+				continue
+			}
+
+			for _, rr := range resp.Answer {
+				if h := rr.Header(); h.Rrtype == qtype && (ttl == 0 || h.Ttl < ttl) {
+					ttl = h.Ttl
+				}
+			}
+
+			break
+		}
+	}
+
+	if ttl == 0 {
+		if lastErr == nil {
+			lastErr = errNoSRVRecords
+		}
+
+		return 0, errors.Wrap(lastErr, "cannot get TTL of the seed list records")
+	}
+
+	return time.Duration(ttl) * time.Second, nil
 }
@@ -49,4 +49,10 @@ func TestGetSeedListFromSRV(t *testing.T) {
 		actual := GetSeedListFromSRV(uri, log)
 		assert.Equal(t, expected, actual)
 	}
+
+	_, err := ResolveSeedList("mongodb+srv://unexistent.com", log)
+	assert.Error(t, err)
+
+	_, err = ResolveSeedList("mongodb+srv://%zz", log)
+	assert.Error(t, err)
 }
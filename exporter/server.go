@@ -44,19 +44,36 @@ type ServerOpts struct {
 }
 
 // RunWebServer runs the main web-server
-func RunWebServer(opts *ServerOpts, exporters []*Exporter, log *slog.Logger) {
+func RunWebServer(opts *ServerOpts, targets *Targets, log *slog.Logger) {
 	mux := http.NewServeMux()
 
-	if len(exporters) == 0 {
+	if len(targets.Exporters()) == 0 {
 		panic("No exporters were built. You must specify --mongodb.uri command argument or MONGODB_URI environment variable")
 	}
 
-	serverMap := buildServerMap(exporters, log)
+	// Targets can change at runtime, so handlers are looked up on every request.
+	mux.HandleFunc(opts.Path, func(w http.ResponseWriter, r *http.Request) {
+		exporters, _ := targets.acquire()
+		defer release(exporters)
 
-	defaultExporter := exporters[0]
-	mux.Handle(opts.Path, defaultExporter.Handler())
-	mux.HandleFunc(opts.MultiTargetPath, multiTargetHandler(serverMap))
-	mux.HandleFunc(opts.OverallTargetPath, OverallTargetsHandler(exporters, log))
+		if len(exporters) == 0 {
+			http.Error(w, "No targets available", http.StatusServiceUnavailable)
+			return
+		}
+		exporters[0].Handler().ServeHTTP(w, r)
+	})
+	mux.HandleFunc(opts.MultiTargetPath, func(w http.ResponseWriter, r *http.Request) {
+		exporters, serverMap := targets.acquire()
+		defer release(exporters)
+
+		multiTargetHandler(serverMap)(w, r)
+	})
+	mux.HandleFunc(opts.OverallTargetPath, func(w http.ResponseWriter, r *http.Request) {
+		exporters, _ := targets.acquire()
+		defer release(exporters)
+
+		OverallTargetsHandler(exporters, log)(w, r)
+	})
 
 	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		_, err := w.Write([]byte(`<html>
@@ -120,6 +137,9 @@ func OverallTargetsHandler(exporters []*Exporter, logger *slog.Logger) http.Hand
 		filters := r.URL.Query()["collect[]"]
 
 		for _, e := range exporters {
+			e.beginScrape()
+			defer e.endScrape()
+
 			ctx, cancel := context.WithTimeout(r.Context(), time.Duration(seconds-e.opts.TimeoutOffset)*time.Second)
 			defer cancel()
 
@@ -144,13 +164,14 @@ func OverallTargetsHandler(exporters []*Exporter, logger *slog.Logger) http.Hand
 			var registry *prometheus.Registry
 			var ti *topologyInfo
 			if client != nil {
-				// Topology can change between requests, so we need to get it every time.
-				ti = newTopologyInfo(ctx, client, e.logger)
+				// Topology can change between requests. It is cached for a short time and
+				// reloaded earlier when the driver reports a state change.
+				ti = e.topology.get(ctx, client)
 				registry = e.makeRegistry(ctx, client, ti, requestOpts)
 			} else {
 				registry = prometheus.NewRegistry()
 				gc := newGeneralCollector(ctx, client, "", e.opts.Logger)
-				registry.MustRegister(gc)
+				prometheus.WrapRegistererWith(e.externalLabels(nil), registry).MustRegister(gc)
 			}
 
 			hostlabels := prometheus.Labels{}
@@ -19,6 +19,10 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/pkg/errors"
@@ -26,20 +30,66 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// Default largest chunk size since MongoDB 6.0, when it is not set in config.settings.
+const defaultMaxChunkSizeBytes = 128 << 20
+
 type shardsCollector struct {
 	ctx        context.Context
 	base       *baseCollector
 	compatible bool
+	// Most chunks measured with dataSize per scrape. 0 disables the sampling.
+	chunkSizeSamples int
+	// Data size from which an unsharded collection is counted as large. 0 disables
+	// the count.
+	largeUnshardedBytes int64
+	// Sharded collections whose data size per shard is read with $collStats: all of
+	// them in discovering mode, else the ones in the collstats namespaces.
+	dataSizeAll        bool
+	dataSizeNamespaces []string
+}
+
+// chunkSizeSample is the estimated size of a chunk of a shard.
+type chunkSizeSample struct {
+	shard string
+	size  float64
+}
+
+// shardedCollectionInfo is the metadata of a sharded collection in config.collections.
+type shardedCollectionInfo struct {
+	ID        string           `bson:"_id"`
+	Key       bson.D           `bson:"key"`
+	Unique    bool             `bson:"unique"`
+	NoBalance bool             `bson:"noBalance"`
+	UUID      primitive.Binary `bson:"uuid"`
+}
+
+// databasePrimary is the primary shard of a database in config.databases.
+type databasePrimary struct {
+	ID      string `bson:"_id"`
+	Primary string `bson:"primary"`
+}
+
+// zoneChunkCount is the number of chunks of a collection on a shard inside the
+// ranges of a zone.
+type zoneChunkCount struct {
+	Shard string `bson:"shard"`
+	Zone  string `bson:"zone"`
+	N     int64  `bson:"n"`
 }
 
 // newShardsCollector creates collector collecting metrics about chunks for shards Mongo.
-func newShardsCollector(ctx context.Context, client *mongo.Client, logger *slog.Logger, compatibleMode bool) *shardsCollector {
+func newShardsCollector(ctx context.Context, client *mongo.Client, logger *slog.Logger, compatibleMode bool, chunkSizeSamples int, largeUnshardedBytes int64, dataSizeAll bool, dataSizeNamespaces []string) *shardsCollector {
 	return &shardsCollector{
-		ctx:        ctx,
-		base:       newBaseCollector(client, logger.With("collector", "shards")),
-		compatible: compatibleMode,
+		ctx:                 ctx,
+		base:                newBaseCollector(client, logger.With("collector", "shards")),
+		compatible:          compatibleMode,
+		chunkSizeSamples:    chunkSizeSamples,
+		largeUnshardedBytes: largeUnshardedBytes,
+		dataSizeAll:         dataSizeAll,
+		dataSizeNamespaces:  dataSizeNamespaces,
 	}
 }
 
@@ -78,37 +128,132 @@ func (d *shardsCollector) collect(ch chan<- prometheus.Metric) {
 		ch <- metric
 	}
 
-	databaseNames, err := client.ListDatabaseNames(d.ctx, bson.D{})
+	shards, err := shardNames(ctx, client)
+	if err != nil {
+		logger.Warn("cannot get the shard names", "error", err)
+	}
+
+	collections := d.getShardedCollections()
+
+	infos, err := shardedCollectionInfos(ctx, client)
+	if err != nil {
+		logger.Warn("cannot get the sharded collections metadata", "error", err)
+	}
+	for _, metric := range collectionInfoMetrics(infos, nil) {
+		ch <- metric
+	}
+
+	primaries, err := databasePrimaries(ctx, client)
 	if err != nil {
-		logger.Error("cannot get database names", "error", err)
+		logger.Warn("cannot get the primary shards of the databases", "error", err)
 	}
-	for _, database := range databaseNames {
-		collections := d.getCollectionsForDBName(database)
-		for _, row := range collections {
-			if len(row) == 0 {
+	for _, metric := range databasePrimaryMetrics(primaries, nil) {
+		ch <- metric
+	}
+
+	if d.largeUnshardedBytes > 0 {
+		sharded := make(map[string]bool, len(infos))
+		for _, info := range infos {
+			sharded[info.ID] = true
+		}
+
+		large := d.largeUnshardedCollections(primaries, sharded)
+		for _, metric := range largeUnshardedMetrics(large, shards, nil) {
+			ch <- metric
+		}
+	}
+
+	shardZones, zoneRanges, err := zoneAssignments(ctx, client)
+	if err != nil {
+		logger.Warn("cannot get the zones", "error", err)
+	}
+	for _, metric := range zoneCoverageMetrics(shardZones, zoneRanges, nil) {
+		ch <- metric
+	}
+
+	// The sampling budget is shared by the collections.
+	samplesLeft, samplesPerCollection := d.chunkSizeSamples, 0
+	maxChunkSize := float64(defaultMaxChunkSizeBytes)
+	if d.chunkSizeSamples > 0 && len(collections) > 0 {
+		samplesPerCollection = max(1, d.chunkSizeSamples/len(collections))
+		if maxChunkSize, err = clusterMaxChunkSize(ctx, client); err != nil {
+			logger.Warn("cannot get the chunk size setting", "error", err)
+		}
+	}
+
+	for _, row := range collections {
+		rowID, ok := row["_id"].(string)
+		if !ok {
+			continue
+		}
+		database, collection, _ := strings.Cut(rowID, ".")
+
+		chunkCounts := make(map[string]float64)
+		for _, c := range d.getChunksForCollection(row) {
+			labels, chunks, success := d.getInfoForChunk(c, database, rowID)
+			if !success {
 				continue
 			}
+			chunkCounts[labels["shard"]] = float64(chunks)
+			for _, metric := range makeMetrics(prefix, primitive.M{"count": chunks}, labels, d.compatible) {
+				ch <- metric
+			}
+			jumbo, _ := c["nJumbo"].(int32)
+			ch <- jumboChunksMetric(jumbo, labels)
+		}
 
-			var ok bool
-			if _, ok = row["_id"]; !ok {
-				continue
+		labels := map[string]string{"database": database, "collection": collection}
+
+		if zoneRanges.namespaces[rowID] {
+			counts, err := zoneChunks(ctx, client, row, rowID)
+			if err != nil {
+				logger.Warn("cannot count the chunks per zone", "namespace", rowID, "error", err)
 			}
-			var rowID string
-			if rowID, ok = row["_id"].(string); !ok {
-				continue
+			for _, metric := range zoneChunkMetrics(counts, shardZones, labels) {
+				ch <- metric
 			}
+		}
 
-			chunks := d.getChunksForCollection(row)
-			for _, c := range chunks {
-				labels, chunks, success := d.getInfoForChunk(c, database, rowID)
-				if !success {
-					continue
-				}
-				for _, metric := range makeMetrics(prefix, primitive.M{"count": chunks}, labels, d.compatible) {
-					ch <- metric
-				}
+		if n := min(samplesLeft, samplesPerCollection); n > 0 {
+			samplesLeft -= n
+
+			samples, err := d.sampleChunkSizes(row, database, rowID, n)
+			if err != nil {
+				logger.Warn("cannot sample the chunk sizes", "namespace", rowID, "error", err)
+			}
+
+			// The collection setting (6.0+) overrides the cluster one.
+			limit := maxChunkSize
+			if v, err := asFloat64(row["maxChunkSizeBytes"]); err == nil && v != nil && *v > 0 {
+				limit = *v
+			}
+
+			for _, metric := range chunkSizeMetrics(samples, limit, labels) {
+				ch <- metric
 			}
 		}
+		for _, metric := range distributionMetrics("mongodb_shards_collection_chunks", "", "number of chunks", chunkCounts, shards, labels) {
+			ch <- metric
+		}
+
+		if !d.dataSizeAll && !namespaceSelected(rowID, d.dataSizeNamespaces) {
+			continue
+		}
+
+		dataSizes, err := collectionDataSizes(ctx, client, database, collection)
+		if err != nil {
+			logger.Warn("cannot get the data size per shard", "namespace", rowID, "error", err)
+			continue
+		}
+
+		sizeDesc := prometheus.NewDesc("mongodb_shards_collection_data_size_bytes",
+			"Uncompressed data size of the sharded collection on the shard ($collStats)", []string{"shard"}, labels)
+		for shard, size := range dataSizes {
+			ch <- prometheus.MustNewConstMetric(sizeDesc, prometheus.GaugeValue, size, shard)
+		}
+		for _, metric := range distributionMetrics("mongodb_shards_collection_data_size", "_bytes", "data size", dataSizes, shards, labels) {
+			ch <- metric
+		}
 	}
 }
 
@@ -148,19 +293,28 @@ func (d *shardsCollector) getInfoForChunk(c primitive.M, database, rowID string)
 	return labels, chunks, true
 }
 
-func (d *shardsCollector) getCollectionsForDBName(database string) []primitive.M {
+// jumboChunksMetric makes the number of jumbo chunks of a collection on a shard.
+func jumboChunksMetric(jumbo int32, labels map[string]string) prometheus.Metric { //nolint:ireturn
+	desc := prometheus.NewDesc("mongodb_shards_collection_chunks_jumbo",
+		"Number of jumbo chunks of the sharded collection on the shard, too large to be moved by the balancer", nil, labels)
+
+	return prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, float64(jumbo))
+}
+
+// getShardedCollections returns the sharded collections of all the databases.
+func (d *shardsCollector) getShardedCollections() []primitive.M {
 	client := d.base.client
 	logger := d.base.logger
 
-	cursor := client.Database("config").Collection("collections")
-	rs, err := cursor.Find(d.ctx, bson.M{"_id": bson.M{"$regex": fmt.Sprintf("^%s.", database), "$options": "i"}})
+	// Before 5.0, dropped collections are kept with the dropped flag.
+	cursor, err := client.Database("config").Collection("collections").Find(d.ctx, bson.M{"dropped": bson.M{"$ne": true}})
 	if err != nil {
-		logger.Error("cannot find _id with database prefix", "database", database, "error", err)
+		logger.Error("cannot find the sharded collections", "error", err)
 		return nil
 	}
 
 	var decoded []bson.M
-	err = rs.All(d.ctx, &decoded)
+	err = cursor.All(d.ctx, &decoded)
 	if err != nil {
 		logger.Error("cannot decode collections", "error", err)
 		return nil
@@ -170,21 +324,14 @@ func (d *shardsCollector) getCollectionsForDBName(database string) []primitive.M
 }
 
 func (d *shardsCollector) getChunksForCollection(row primitive.M) []bson.M {
-	var chunksMatchPredicate bson.M
-	if _, ok := row["timestamp"]; ok {
-		if uuid, ok := row["uuid"]; ok {
-			chunksMatchPredicate = bson.M{"uuid": uuid}
-		}
-	} else {
-		if id, ok := row["_id"]; ok {
-			chunksMatchPredicate = bson.M{"_id": id}
-		}
-	}
-
 	aggregation := bson.A{
-		bson.M{"$match": chunksMatchPredicate},
-		bson.M{"$group": bson.M{"_id": "$shard", "cnt": bson.M{"$sum": 1}}},
-		bson.M{"$project": bson.M{"_id": 0, "shard": "$_id", "nChunks": "$cnt"}},
+		bson.M{"$match": chunksMatchPredicate(row)},
+		bson.M{"$group": bson.M{
+			"_id":   "$shard",
+			"cnt":   bson.M{"$sum": 1},
+			"jumbo": bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{"$jumbo", true}}, 1, 0}}},
+		}},
+		bson.M{"$project": bson.M{"_id": 0, "shard": "$_id", "nChunks": "$cnt", "nJumbo": "$jumbo"}},
 		bson.M{"$sort": bson.M{"shard": 1}},
 	}
 
@@ -207,6 +354,137 @@ func (d *shardsCollector) getChunksForCollection(row primitive.M) []bson.M {
 	return chunks
 }
 
+// chunksMatchPredicate returns the filter of config.chunks for the collection, by
+// UUID since 5.0 or by namespace before.
+func chunksMatchPredicate(row primitive.M) bson.M {
+	if _, ok := row["timestamp"]; ok {
+		if uuid, ok := row["uuid"]; ok {
+			return bson.M{"uuid": uuid}
+		}
+	} else {
+		if id, ok := row["_id"]; ok {
+			return bson.M{"_id": id}
+		}
+	}
+
+	return nil
+}
+
+// sampleChunkSizes estimates the size of up to n chunks of the collection with
+// dataSize. Jumbo chunks are measured first, then randomly picked chunks.
+func (d *shardsCollector) sampleChunkSizes(row primitive.M, database, ns string, n int) ([]chunkSizeSample, error) {
+	client := d.base.client
+	chunks := client.Database("config").Collection("chunks")
+	match := chunksMatchPredicate(row)
+	if match == nil {
+		return nil, nil
+	}
+
+	type chunk struct {
+		Shard string   `bson:"shard"`
+		Min   bson.Raw `bson:"min"`
+		Max   bson.Raw `bson:"max"`
+	}
+
+	var picked []chunk
+
+	jumboMatch := maps.Clone(match)
+	jumboMatch["jumbo"] = true
+	cursor, err := chunks.Find(d.ctx, jumboMatch, options.Find().SetLimit(int64(n)))
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot get the jumbo chunks")
+	}
+	if err := cursor.All(d.ctx, &picked); err != nil {
+		return nil, errors.Wrap(err, "cannot decode the jumbo chunks")
+	}
+
+	if rest := n - len(picked); rest > 0 {
+		otherMatch := maps.Clone(match)
+		otherMatch["jumbo"] = bson.M{"$ne": true}
+		cursor, err := chunks.Aggregate(d.ctx, bson.A{
+			bson.M{"$match": otherMatch},
+			bson.M{"$sample": bson.M{"size": rest}},
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "cannot sample the chunks")
+		}
+
+		var others []chunk
+		if err := cursor.All(d.ctx, &others); err != nil {
+			return nil, errors.Wrap(err, "cannot decode the sampled chunks")
+		}
+		picked = append(picked, others...)
+	}
+
+	samples := make([]chunkSizeSample, 0, len(picked))
+	for _, c := range picked {
+		cmd := bson.D{
+			{Key: "dataSize", Value: ns},
+			{Key: "keyPattern", Value: row["key"]},
+			{Key: "min", Value: c.Min},
+			{Key: "max", Value: c.Max},
+			// Uses the average document size instead of reading the documents.
+			{Key: "estimate", Value: true},
+		}
+
+		var res struct {
+			Size float64 `bson:"size"`
+		}
+		if err := client.Database(database).RunCommand(d.ctx, cmd).Decode(&res); err != nil {
+			return samples, errors.Wrap(err, "cannot get dataSize")
+		}
+
+		samples = append(samples, chunkSizeSample{shard: c.Shard, size: res.Size})
+	}
+
+	return samples, nil
+}
+
+// clusterMaxChunkSize returns the largest chunk size set in config.settings.
+func clusterMaxChunkSize(ctx context.Context, client *mongo.Client) (float64, error) {
+	var setting struct {
+		Value float64 `bson:"value"` // In MB.
+	}
+
+	err := client.Database("config").Collection("settings").FindOne(ctx, bson.M{"_id": "chunksize"}).Decode(&setting)
+	if errors.Is(err, mongo.ErrNoDocuments) || (err == nil && setting.Value <= 0) {
+		return defaultMaxChunkSizeBytes, nil
+	}
+	if err != nil {
+		return defaultMaxChunkSizeBytes, err
+	}
+
+	return setting.Value * (1 << 20), nil //nolint:mnd
+}
+
+// chunkSizeMetrics makes the per shard metrics of the sampled chunk sizes: the
+// largest size and the number of chunks larger than limit.
+func chunkSizeMetrics(samples []chunkSizeSample, limit float64, labels map[string]string) []prometheus.Metric {
+	largest := make(map[string]float64)
+	oversized := make(map[string]float64)
+	for _, s := range samples {
+		largest[s.shard] = max(largest[s.shard], s.size)
+		if s.size > limit {
+			oversized[s.shard]++
+		}
+	}
+
+	largestDesc := prometheus.NewDesc("mongodb_shards_collection_sampled_chunk_max_size_bytes",
+		"Largest estimated size of the chunks of the collection sampled with dataSize on the shard", []string{"shard"}, labels)
+	oversizedDesc := prometheus.NewDesc("mongodb_shards_collection_sampled_chunks_oversized",
+		"Number of chunks of the collection sampled with dataSize on the shard larger than the maximum chunk size", []string{"shard"}, labels)
+
+	metrics := make([]prometheus.Metric, 0, 2*len(largest)) //nolint:mnd
+	for shard, size := range largest {
+		metrics = append(metrics,
+			prometheus.MustNewConstMetric(largestDesc, prometheus.GaugeValue, size, shard),
+			prometheus.MustNewConstMetric(oversizedDesc, prometheus.GaugeValue, oversized[shard], shard),
+		)
+	}
+
+	return metrics
+}
+
 func chunksTotal(ctx context.Context, client *mongo.Client) (prometheus.Metric, error) { //nolint:ireturn
 	n, err := client.Database("config").Collection("chunks").CountDocuments(ctx, bson.M{})
 	if err != nil {
@@ -262,4 +540,435 @@ func chunksTotalPerShard(ctx context.Context, client *mongo.Client) ([]prometheu
 	return metrics, nil
 }
 
+// shardNames returns the names of the shards of the cluster.
+func shardNames(ctx context.Context, client *mongo.Client) ([]string, error) {
+	cursor, err := client.Database("config").Collection("shards").Find(ctx, bson.M{}, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot get config.shards")
+	}
+
+	var shards []struct {
+		ID string `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &shards); err != nil {
+		return nil, errors.Wrap(err, "cannot decode config.shards")
+	}
+
+	names := make([]string, 0, len(shards))
+	for _, s := range shards {
+		names = append(names, s.ID)
+	}
+
+	return names, nil
+}
+
+// shardedCollectionInfos returns the metadata of the sharded collections. The shard
+// key is decoded in order, unlike getShardedCollections.
+func shardedCollectionInfos(ctx context.Context, client *mongo.Client) ([]shardedCollectionInfo, error) {
+	projection := bson.M{"_id": 1, "key": 1, "unique": 1, "noBalance": 1, "uuid": 1}
+	cursor, err := client.Database("config").Collection("collections").Find(ctx, bson.M{"dropped": bson.M{"$ne": true}},
+		options.Find().SetProjection(projection))
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot get config.collections")
+	}
+
+	var infos []shardedCollectionInfo
+	if err := cursor.All(ctx, &infos); err != nil {
+		return nil, errors.Wrap(err, "cannot decode config.collections")
+	}
+
+	return infos, nil
+}
+
+// databasePrimaries returns the primary shard of each database.
+func databasePrimaries(ctx context.Context, client *mongo.Client) ([]databasePrimary, error) {
+	cursor, err := client.Database("config").Collection("databases").Find(ctx, bson.M{},
+		options.Find().SetProjection(bson.M{"_id": 1, "primary": 1}))
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot get config.databases")
+	}
+
+	var primaries []databasePrimary
+	if err := cursor.All(ctx, &primaries); err != nil {
+		return nil, errors.Wrap(err, "cannot decode config.databases")
+	}
+
+	return primaries, nil
+}
+
+// largeUnshardedCollections counts by primary shard the unsharded collections whose
+// data size is at least largeUnshardedBytes.
+func (d *shardsCollector) largeUnshardedCollections(primaries []databasePrimary, sharded map[string]bool) map[string]int {
+	client := d.base.client
+	logger := d.base.logger
+
+	large := make(map[string]int)
+	for _, p := range primaries {
+		// Older versions list the config database, held by the config servers.
+		if p.ID == "config" || p.ID == "admin" {
+			continue
+		}
+
+		filter := bson.M{"type": "collection", "name": bson.M{"$not": bson.M{"$regex": "^system\\."}}}
+		names, err := client.Database(p.ID).ListCollectionNames(d.ctx, filter)
+		if err != nil {
+			logger.Warn("cannot list the collections", "database", p.ID, "error", err)
+			continue
+		}
+
+		for _, name := range names {
+			if sharded[p.ID+"."+name] {
+				continue
+			}
+
+			sizes, err := collectionDataSizes(d.ctx, client, p.ID, name)
+			if err != nil {
+				logger.Warn("cannot get the data size", "namespace", p.ID+"."+name, "error", err)
+				continue
+			}
+
+			total := 0.0
+			for _, size := range sizes {
+				total += size
+			}
+			if total >= float64(d.largeUnshardedBytes) {
+				large[p.Primary]++
+			}
+		}
+	}
+
+	return large
+}
+
+// collectionInfoMetrics makes an info metric for each sharded collection with its
+// shard key, as field:direction pairs, and its settings.
+func collectionInfoMetrics(infos []shardedCollectionInfo, labels map[string]string) []prometheus.Metric {
+	desc := prometheus.NewDesc("mongodb_shards_collection_info",
+		"Shard key and settings of the sharded collection",
+		[]string{"database", "collection", "shard_key", "hashed", "unique", "no_balance", "uuid"}, labels)
+
+	metrics := make([]prometheus.Metric, 0, len(infos))
+	for _, info := range infos {
+		database, collection, _ := strings.Cut(info.ID, ".")
+
+		fields := make([]string, 0, len(info.Key))
+		hashed := false
+		for _, e := range info.Key {
+			fields = append(fields, fmt.Sprintf("%s:%v", e.Key, e.Value))
+			if e.Value == "hashed" {
+				hashed = true
+			}
+		}
+
+		metrics = append(metrics, prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, 1,
+			database, collection, strings.Join(fields, ","), strconv.FormatBool(hashed),
+			strconv.FormatBool(info.Unique), strconv.FormatBool(info.NoBalance), formatUUID(info.UUID)))
+	}
+
+	return metrics
+}
+
+// formatUUID formats a binary UUID in its canonical form.
+func formatUUID(b primitive.Binary) string {
+	if len(b.Data) != 16 { //nolint:mnd
+		return ""
+	}
+
+	d := b.Data
+	return fmt.Sprintf("%x-%x-%x-%x-%x", d[0:4], d[4:6], d[6:8], d[8:10], d[10:16])
+}
+
+// databasePrimaryMetrics makes an info metric for each database with its primary
+// shard, holding its unsharded collections.
+func databasePrimaryMetrics(primaries []databasePrimary, labels map[string]string) []prometheus.Metric {
+	desc := prometheus.NewDesc("mongodb_shards_database_primary_info",
+		"Primary shard of the database, holding its unsharded collections", []string{"database", "shard"}, labels)
+
+	metrics := make([]prometheus.Metric, 0, len(primaries))
+	for _, p := range primaries {
+		metrics = append(metrics, prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, 1, p.ID, p.Primary))
+	}
+
+	return metrics
+}
+
+// largeUnshardedMetrics makes the number of large unsharded collections by primary
+// shard. Shards without any count as zero.
+func largeUnshardedMetrics(large map[string]int, shards []string, labels map[string]string) []prometheus.Metric {
+	desc := prometheus.NewDesc("mongodb_shards_large_unsharded_collections",
+		"Number of unsharded collections with a data size over the threshold, by primary shard", []string{"shard"}, labels)
+
+	all := make(map[string]int, len(shards))
+	for _, shard := range shards {
+		all[shard] = 0
+	}
+	maps.Copy(all, large)
+
+	metrics := make([]prometheus.Metric, 0, len(all))
+	for _, shard := range slices.Sorted(maps.Keys(all)) {
+		metrics = append(metrics, prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, float64(all[shard]), shard))
+	}
+
+	return metrics
+}
+
+// zoneRangeCounts is the number of ranges of each zone in config.tags and the
+// namespaces having zone ranges.
+type zoneRangeCounts struct {
+	ranges     map[string]int
+	namespaces map[string]bool
+}
+
+// zoneAssignments returns the zones of each shard from config.shards and the zone
+// ranges of config.tags.
+func zoneAssignments(ctx context.Context, client *mongo.Client) (map[string][]string, zoneRangeCounts, error) {
+	zoneRanges := zoneRangeCounts{ranges: make(map[string]int), namespaces: make(map[string]bool)}
+	config := client.Database("config")
+
+	cursor, err := config.Collection("shards").Find(ctx, bson.M{}, options.Find().SetProjection(bson.M{"_id": 1, "tags": 1}))
+	if err != nil {
+		return nil, zoneRanges, errors.Wrap(err, "cannot get config.shards")
+	}
+
+	var shards []struct {
+		ID   string   `bson:"_id"`
+		Tags []string `bson:"tags"`
+	}
+	if err := cursor.All(ctx, &shards); err != nil {
+		return nil, zoneRanges, errors.Wrap(err, "cannot decode config.shards")
+	}
+
+	shardZones := make(map[string][]string, len(shards))
+	for _, s := range shards {
+		shardZones[s.ID] = s.Tags
+	}
+
+	cursor, err = config.Collection("tags").Find(ctx, bson.M{}, options.Find().SetProjection(bson.M{"ns": 1, "tag": 1}))
+	if err != nil {
+		return shardZones, zoneRanges, errors.Wrap(err, "cannot get config.tags")
+	}
+
+	var ranges []struct {
+		NS  string `bson:"ns"`
+		Tag string `bson:"tag"`
+	}
+	if err := cursor.All(ctx, &ranges); err != nil {
+		return shardZones, zoneRanges, errors.Wrap(err, "cannot decode config.tags")
+	}
+
+	for _, r := range ranges {
+		zoneRanges.ranges[r.Tag]++
+		zoneRanges.namespaces[r.NS] = true
+	}
+
+	return shardZones, zoneRanges, nil
+}
+
+// zoneChunks counts the chunks of the collection by shard and by zone range holding
+// them. A chunk is in a zone when it is within one of its ranges, chunks outside of
+// all the ranges are not counted.
+func zoneChunks(ctx context.Context, client *mongo.Client, row primitive.M, ns string) ([]zoneChunkCount, error) {
+	aggregation := bson.A{
+		bson.M{"$match": chunksMatchPredicate(row)},
+		bson.M{"$lookup": bson.M{
+			"from": "tags",
+			"let":  bson.M{"min": "$min", "max": "$max"},
+			"pipeline": bson.A{
+				bson.M{"$match": bson.M{"ns": ns}},
+				bson.M{"$match": bson.M{"$expr": bson.M{"$and": bson.A{
+					bson.M{"$gte": bson.A{"$$min", "$min"}},
+					bson.M{"$lte": bson.A{"$$max", "$max"}},
+				}}}},
+				bson.M{"$project": bson.M{"_id": 0, "tag": 1}},
+			},
+			"as": "zones",
+		}},
+		bson.M{"$unwind": "$zones"},
+		bson.M{"$group": bson.M{
+			"_id": bson.M{"shard": "$shard", "zone": "$zones.tag"},
+			"n":   bson.M{"$sum": 1},
+		}},
+		bson.M{"$project": bson.M{"_id": 0, "shard": "$_id.shard", "zone": "$_id.zone", "n": 1}},
+	}
+
+	cursor, err := client.Database("config").Collection("chunks").Aggregate(ctx, aggregation)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot count the chunks by zone")
+	}
+
+	var counts []zoneChunkCount
+	if err := cursor.All(ctx, &counts); err != nil {
+		return nil, errors.Wrap(err, "cannot decode the chunks by zone")
+	}
+
+	return counts, nil
+}
+
+// zoneChunkMetrics makes the number of chunks of a collection in each zone by shard
+// and the number of chunks of each zone sitting on a shard outside of the zone.
+func zoneChunkMetrics(counts []zoneChunkCount, shardZones map[string][]string, labels map[string]string) []prometheus.Metric {
+	chunksDesc := prometheus.NewDesc("mongodb_shards_collection_zone_chunks",
+		"Number of chunks of the collection within the ranges of the zone, by shard", []string{"zone", "shard"}, labels)
+	outOfZoneDesc := prometheus.NewDesc("mongodb_shards_collection_zone_chunks_out_of_zone",
+		"Number of chunks of the collection within the ranges of the zone sitting on a shard outside of the zone", []string{"zone"}, labels)
+
+	metrics := make([]prometheus.Metric, 0, len(counts))
+	outOfZone := make(map[string]int64)
+	for _, c := range counts {
+		metrics = append(metrics, prometheus.MustNewConstMetric(chunksDesc, prometheus.GaugeValue, float64(c.N), c.Zone, c.Shard))
+
+		n := outOfZone[c.Zone]
+		if !slices.Contains(shardZones[c.Shard], c.Zone) {
+			n += c.N
+		}
+		outOfZone[c.Zone] = n
+	}
+
+	for _, zone := range slices.Sorted(maps.Keys(outOfZone)) {
+		metrics = append(metrics, prometheus.MustNewConstMetric(outOfZoneDesc, prometheus.GaugeValue, float64(outOfZone[zone]), zone))
+	}
+
+	return metrics
+}
+
+// zoneCoverageMetrics makes the number of shards and ranges of each zone, the number
+// of zones of each shard, and the number of zones without shards and shards without
+// zones. Clusters without zones get no metrics.
+func zoneCoverageMetrics(shardZones map[string][]string, zoneRanges zoneRangeCounts, labels map[string]string) []prometheus.Metric {
+	zoneShards := make(map[string]int)
+	for zone := range zoneRanges.ranges {
+		zoneShards[zone] = 0
+	}
+	for _, zones := range shardZones {
+		for _, zone := range zones {
+			zoneShards[zone]++
+		}
+	}
+
+	if len(zoneShards) == 0 {
+		return nil
+	}
+
+	gauge := func(name, help string, labelNames ...string) *prometheus.Desc {
+		return prometheus.NewDesc("mongodb_shards_"+name, help, labelNames, labels)
+	}
+	zoneShardsDesc := gauge("zone_shards", "Number of shards assigned to the zone", "zone")
+	zoneRangesDesc := gauge("zone_ranges", "Number of shard key ranges of the zone in config.tags", "zone")
+	shardZonesDesc := gauge("shard_zones", "Number of zones the shard is assigned to", "shard")
+	zonesWithoutShardsDesc := gauge("zones_without_shards", "Number of zones not assigned to any shard")
+	shardsWithoutZonesDesc := gauge("shards_without_zones", "Number of shards not assigned to any zone")
+
+	var metrics []prometheus.Metric
+	zonesWithoutShards, shardsWithoutZones := 0, 0
+
+	for _, zone := range slices.Sorted(maps.Keys(zoneShards)) {
+		if zoneShards[zone] == 0 {
+			zonesWithoutShards++
+		}
+		metrics = append(metrics,
+			prometheus.MustNewConstMetric(zoneShardsDesc, prometheus.GaugeValue, float64(zoneShards[zone]), zone),
+			prometheus.MustNewConstMetric(zoneRangesDesc, prometheus.GaugeValue, float64(zoneRanges.ranges[zone]), zone),
+		)
+	}
+
+	for _, shard := range slices.Sorted(maps.Keys(shardZones)) {
+		if len(shardZones[shard]) == 0 {
+			shardsWithoutZones++
+		}
+		metrics = append(metrics, prometheus.MustNewConstMetric(shardZonesDesc, prometheus.GaugeValue, float64(len(shardZones[shard])), shard))
+	}
+
+	return append(metrics,
+		prometheus.MustNewConstMetric(zonesWithoutShardsDesc, prometheus.GaugeValue, float64(zonesWithoutShards)),
+		prometheus.MustNewConstMetric(shardsWithoutZonesDesc, prometheus.GaugeValue, float64(shardsWithoutZones)),
+	)
+}
+
+// namespaceSelected returns true if the namespace or its database is in the list.
+func namespaceSelected(ns string, namespaces []string) bool {
+	database, _ := splitNamespace(ns)
+	for _, n := range namespaces {
+		if n == ns || n == database {
+			return true
+		}
+	}
+
+	return false
+}
+
+// collectionDataSizes returns the uncompressed data size of the collection by shard.
+func collectionDataSizes(ctx context.Context, client *mongo.Client, database, collection string) (map[string]float64, error) {
+	aggregation := bson.A{bson.M{"$collStats": bson.M{"storageStats": bson.M{}}}}
+
+	cursor, err := client.Database(database).Collection(collection).Aggregate(ctx, aggregation)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot get $collStats")
+	}
+
+	var stats []struct {
+		Shard        string `bson:"shard"`
+		StorageStats struct {
+			Size float64 `bson:"size"`
+		} `bson:"storageStats"`
+	}
+	if err := cursor.All(ctx, &stats); err != nil {
+		return nil, errors.Wrap(err, "cannot decode $collStats")
+	}
+
+	sizes := make(map[string]float64, len(stats))
+	for _, s := range stats {
+		sizes[s.Shard] += s.StorageStats.Size
+	}
+
+	return sizes, nil
+}
+
+// distributionMetrics makes the metrics comparing a value (what) of a sharded
+// collection across the shards: the spread between the largest and the smallest value, the
+// standard deviation and the shard with the largest value. Shards missing from values
+// count as zero.
+func distributionMetrics(prefix, unit, what string, values map[string]float64, shards []string, labels map[string]string) []prometheus.Metric {
+	all := make(map[string]float64, len(shards))
+	for _, shard := range shards {
+		all[shard] = 0
+	}
+	maps.Copy(all, values)
+
+	if len(all) == 0 {
+		return nil
+	}
+
+	names := slices.Sorted(maps.Keys(all))
+	maxShard := names[0]
+	minValue, sum := all[maxShard], 0.0
+	for _, shard := range names {
+		v := all[shard]
+		if v > all[maxShard] {
+			maxShard = shard
+		}
+		minValue = min(minValue, v)
+		sum += v
+	}
+
+	mean := sum / float64(len(all))
+	variance := 0.0
+	for _, v := range all {
+		variance += (v - mean) * (v - mean)
+	}
+	variance /= float64(len(all))
+
+	spreadDesc := prometheus.NewDesc(prefix+"_spread"+unit,
+		"Difference between the largest and the smallest "+what+" of the collection across the shards", nil, labels)
+	stddevDesc := prometheus.NewDesc(prefix+"_stddev"+unit,
+		"Standard deviation of the "+what+" of the collection across the shards", nil, labels)
+	maxShardDesc := prometheus.NewDesc(prefix+"_max_shard"+unit,
+		"Largest "+what+" of the collection across the shards, labelled with the shard holding it", []string{"shard"}, labels)
+
+	return []prometheus.Metric{
+		prometheus.MustNewConstMetric(spreadDesc, prometheus.GaugeValue, all[maxShard]-minValue),
+		prometheus.MustNewConstMetric(stddevDesc, prometheus.GaugeValue, math.Sqrt(variance)),
+		prometheus.MustNewConstMetric(maxShardDesc, prometheus.GaugeValue, all[maxShard], maxShard),
+	}
+}
+
 var _ prometheus.Collector = (*shardsCollector)(nil)
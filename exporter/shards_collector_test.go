@@ -18,12 +18,17 @@ package exporter
 import (
 	"context"
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/prometheus/common/promslog"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 
 	"github.com/percona/mongodb_exporter/internal/tu"
 )
@@ -35,7 +40,7 @@ func TestShardsCollector(t *testing.T) {
 	defer cancel()
 
 	client := tu.DefaultTestClientMongoS(ctx, t)
-	c := newShardsCollector(ctx, client, promslog.New(&promslog.Config{}), false)
+	c := newShardsCollector(ctx, client, promslog.New(&promslog.Config{}), false, 0, 0, true, nil)
 
 	reg := prometheus.NewPedanticRegistry()
 	if err := reg.Register(c); err != nil {
@@ -67,3 +72,161 @@ func TestShardsCollector(t *testing.T) {
 		assert.Contains(t, res, v)
 	}
 }
+
+func TestDistributionMetrics(t *testing.T) {
+	t.Parallel()
+
+	labels := map[string]string{"database": "test", "collection": "shard"}
+	// rs3 has no chunks of the collection.
+	chunks := map[string]float64{"rs1": 10, "rs2": 4}
+
+	expected := strings.NewReader(`
+	# HELP mongodb_shards_collection_chunks_max_shard Largest number of chunks of the collection across the shards, labelled with the shard holding it
+	# TYPE mongodb_shards_collection_chunks_max_shard gauge
+	mongodb_shards_collection_chunks_max_shard{collection="shard",database="test",shard="rs1"} 10
+	# HELP mongodb_shards_collection_chunks_spread Difference between the largest and the smallest number of chunks of the collection across the shards
+	# TYPE mongodb_shards_collection_chunks_spread gauge
+	mongodb_shards_collection_chunks_spread{collection="shard",database="test"} 10
+	# HELP mongodb_shards_collection_chunks_stddev Standard deviation of the number of chunks of the collection across the shards
+	# TYPE mongodb_shards_collection_chunks_stddev gauge
+	mongodb_shards_collection_chunks_stddev{collection="shard",database="test"} 4.109609335312651` + "\n")
+	metrics := distributionMetrics("mongodb_shards_collection_chunks", "", "number of chunks", chunks, []string{"rs1", "rs2", "rs3"}, labels)
+	require.NoError(t, testutil.CollectAndCompare(metricsCollector(metrics), expected))
+
+	assert.Empty(t, distributionMetrics("mongodb_shards_collection_chunks", "", "number of chunks", nil, nil, labels))
+}
+
+func TestChunkSizeMetrics(t *testing.T) {
+	t.Parallel()
+
+	labels := map[string]string{"database": "test", "collection": "shard"}
+	samples := []chunkSizeSample{
+		{shard: "rs1", size: 200 << 20},
+		{shard: "rs1", size: 10 << 20},
+		{shard: "rs2", size: 64 << 20},
+	}
+
+	expected := strings.NewReader(`
+	# HELP mongodb_shards_collection_sampled_chunk_max_size_bytes Largest estimated size of the chunks of the collection sampled with dataSize on the shard
+	# TYPE mongodb_shards_collection_sampled_chunk_max_size_bytes gauge
+	mongodb_shards_collection_sampled_chunk_max_size_bytes{collection="shard",database="test",shard="rs1"} 2.097152e+08
+	mongodb_shards_collection_sampled_chunk_max_size_bytes{collection="shard",database="test",shard="rs2"} 6.7108864e+07
+	# HELP mongodb_shards_collection_sampled_chunks_oversized Number of chunks of the collection sampled with dataSize on the shard larger than the maximum chunk size
+	# TYPE mongodb_shards_collection_sampled_chunks_oversized gauge
+	mongodb_shards_collection_sampled_chunks_oversized{collection="shard",database="test",shard="rs1"} 1
+	mongodb_shards_collection_sampled_chunks_oversized{collection="shard",database="test",shard="rs2"} 0` + "\n")
+	require.NoError(t, testutil.CollectAndCompare(metricsCollector(chunkSizeMetrics(samples, defaultMaxChunkSizeBytes, labels)), expected))
+}
+
+func TestZoneMetrics(t *testing.T) {
+	t.Parallel()
+
+	shardZones := map[string][]string{
+		"rs1": {"EU"},
+		"rs2": {"US"},
+		"rs3": nil,
+	}
+	zoneRanges := zoneRangeCounts{ranges: map[string]int{"EU": 2, "US": 1, "APAC": 1}}
+
+	expected := strings.NewReader(`
+	# HELP mongodb_shards_shard_zones Number of zones the shard is assigned to
+	# TYPE mongodb_shards_shard_zones gauge
+	mongodb_shards_shard_zones{shard="rs1"} 1
+	mongodb_shards_shard_zones{shard="rs2"} 1
+	mongodb_shards_shard_zones{shard="rs3"} 0
+	# HELP mongodb_shards_shards_without_zones Number of shards not assigned to any zone
+	# TYPE mongodb_shards_shards_without_zones gauge
+	mongodb_shards_shards_without_zones 1
+	# HELP mongodb_shards_zone_ranges Number of shard key ranges of the zone in config.tags
+	# TYPE mongodb_shards_zone_ranges gauge
+	mongodb_shards_zone_ranges{zone="APAC"} 1
+	mongodb_shards_zone_ranges{zone="EU"} 2
+	mongodb_shards_zone_ranges{zone="US"} 1
+	# HELP mongodb_shards_zone_shards Number of shards assigned to the zone
+	# TYPE mongodb_shards_zone_shards gauge
+	mongodb_shards_zone_shards{zone="APAC"} 0
+	mongodb_shards_zone_shards{zone="EU"} 1
+	mongodb_shards_zone_shards{zone="US"} 1
+	# HELP mongodb_shards_zones_without_shards Number of zones not assigned to any shard
+	# TYPE mongodb_shards_zones_without_shards gauge
+	mongodb_shards_zones_without_shards 1` + "\n")
+	require.NoError(t, testutil.CollectAndCompare(metricsCollector(zoneCoverageMetrics(shardZones, zoneRanges, nil)), expected))
+
+	// Clusters without zones get no metrics.
+	assert.Empty(t, zoneCoverageMetrics(map[string][]string{"rs1": nil}, zoneRangeCounts{}, nil))
+
+	counts := []zoneChunkCount{
+		{Shard: "rs1", Zone: "EU", N: 10},
+		{Shard: "rs3", Zone: "EU", N: 2},
+		{Shard: "rs2", Zone: "US", N: 4},
+	}
+	labels := map[string]string{"database": "test", "collection": "users"}
+
+	expected = strings.NewReader(`
+	# HELP mongodb_shards_collection_zone_chunks Number of chunks of the collection within the ranges of the zone, by shard
+	# TYPE mongodb_shards_collection_zone_chunks gauge
+	mongodb_shards_collection_zone_chunks{collection="users",database="test",shard="rs1",zone="EU"} 10
+	mongodb_shards_collection_zone_chunks{collection="users",database="test",shard="rs2",zone="US"} 4
+	mongodb_shards_collection_zone_chunks{collection="users",database="test",shard="rs3",zone="EU"} 2
+	# HELP mongodb_shards_collection_zone_chunks_out_of_zone Number of chunks of the collection within the ranges of the zone sitting on a shard outside of the zone
+	# TYPE mongodb_shards_collection_zone_chunks_out_of_zone gauge
+	mongodb_shards_collection_zone_chunks_out_of_zone{collection="users",database="test",zone="EU"} 2
+	mongodb_shards_collection_zone_chunks_out_of_zone{collection="users",database="test",zone="US"} 0` + "\n")
+	require.NoError(t, testutil.CollectAndCompare(metricsCollector(zoneChunkMetrics(counts, shardZones, labels)), expected))
+}
+
+func TestShardedMetadataMetrics(t *testing.T) {
+	t.Parallel()
+
+	infos := []shardedCollectionInfo{
+		{
+			ID:   "test.users",
+			Key:  bson.D{{Key: "tenant", Value: int32(1)}, {Key: "_id", Value: "hashed"}},
+			UUID: primitive.Binary{Subtype: 4, Data: []byte{0x0b, 0x5d, 0x9e, 0xa7, 0x7c, 0x44, 0x4b, 0x8a, 0x9e, 0x0f, 0x05, 0xa8, 0xc1, 0xa6, 0xa7, 0xf2}},
+		},
+		{ID: "test.orders", Key: bson.D{{Key: "orderId", Value: int32(1)}}, Unique: true, NoBalance: true},
+	}
+	primaries := []databasePrimary{{ID: "test", Primary: "rs1"}}
+
+	expected := strings.NewReader(`
+	# HELP mongodb_shards_collection_info Shard key and settings of the sharded collection
+	# TYPE mongodb_shards_collection_info gauge
+	mongodb_shards_collection_info{collection="orders",database="test",hashed="false",no_balance="true",shard_key="orderId:1",unique="true",uuid=""} 1
+	mongodb_shards_collection_info{collection="users",database="test",hashed="true",no_balance="false",shard_key="tenant:1,_id:hashed",unique="false",uuid="0b5d9ea7-7c44-4b8a-9e0f-05a8c1a6a7f2"} 1
+	# HELP mongodb_shards_database_primary_info Primary shard of the database, holding its unsharded collections
+	# TYPE mongodb_shards_database_primary_info gauge
+	mongodb_shards_database_primary_info{database="test",shard="rs1"} 1
+	# HELP mongodb_shards_large_unsharded_collections Number of unsharded collections with a data size over the threshold, by primary shard
+	# TYPE mongodb_shards_large_unsharded_collections gauge
+	mongodb_shards_large_unsharded_collections{shard="rs1"} 3
+	mongodb_shards_large_unsharded_collections{shard="rs2"} 0` + "\n")
+
+	metrics := collectionInfoMetrics(infos, nil)
+	metrics = append(metrics, databasePrimaryMetrics(primaries, nil)...)
+	metrics = append(metrics, largeUnshardedMetrics(map[string]int{"rs1": 3}, []string{"rs1", "rs2"}, nil)...)
+	require.NoError(t, testutil.CollectAndCompare(metricsCollector(metrics), expected))
+}
+
+func TestNamespaceSelected(t *testing.T) {
+	t.Parallel()
+
+	namespaces := []string{"db1", "db2.col2"}
+	assert.True(t, namespaceSelected("db1.col1", namespaces))
+	assert.True(t, namespaceSelected("db2.col2", namespaces))
+	assert.False(t, namespaceSelected("db2.col1", namespaces))
+	assert.False(t, namespaceSelected("db3.col1", namespaces))
+	assert.False(t, namespaceSelected("db1.col1", nil))
+}
+
+func TestJumboChunksMetric(t *testing.T) {
+	t.Parallel()
+
+	labels := map[string]string{"database": "db1", "collection": "col1", "shard": "rs1"}
+	metric := jumboChunksMetric(2, labels)
+
+	expected := strings.NewReader(`
+	# HELP mongodb_shards_collection_chunks_jumbo Number of jumbo chunks of the sharded collection on the shard, too large to be moved by the balancer
+	# TYPE mongodb_shards_collection_chunks_jumbo gauge
+	mongodb_shards_collection_chunks_jumbo{collection="col1",database="db1",shard="rs1"} 2` + "\n")
+	require.NoError(t, testutil.CollectAndCompare(metricsCollector([]prometheus.Metric{metric}), expected))
+}
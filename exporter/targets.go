@@ -0,0 +1,255 @@
+// mongodb_exporter
+// Copyright (C) 2017 Percona LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Targets holds the exporters served by the web server. The list can be replaced
+// at runtime, for example when the SRV records of a cluster change.
+type Targets struct {
+	rw        sync.RWMutex
+	exporters []*Exporter
+	serverMap ServerMap
+	logger    *slog.Logger
+}
+
+// NewTargets creates a Targets instance serving the given exporters.
+func NewTargets(exporters []*Exporter, logger *slog.Logger) *Targets {
+	t := &Targets{logger: logger}
+	t.Set(exporters)
+
+	return t
+}
+
+// Exporters returns a copy of the current list of exporters.
+func (t *Targets) Exporters() []*Exporter {
+	t.rw.RLock()
+	defer t.rw.RUnlock()
+
+	return append([]*Exporter(nil), t.exporters...)
+}
+
+// Set replaces the list of exporters.
+func (t *Targets) Set(exporters []*Exporter) {
+	serverMap := buildServerMap(exporters, t.logger)
+
+	t.rw.Lock()
+	t.exporters = append([]*Exporter(nil), exporters...)
+	t.serverMap = serverMap
+	t.rw.Unlock()
+}
+
+func (t *Targets) getServerMap() ServerMap {
+	t.rw.RLock()
+	defer t.rw.RUnlock()
+
+	return t.serverMap
+}
+
+// acquire returns the current exporters and server map for a request. The exporters
+// are marked as serving it while the list cannot change, so a removed exporter is
+// not closed before the request is done. release must be called afterwards.
+func (t *Targets) acquire() ([]*Exporter, ServerMap) {
+	t.rw.RLock()
+	defer t.rw.RUnlock()
+
+	for _, e := range t.exporters {
+		e.beginScrape()
+	}
+
+	return append([]*Exporter(nil), t.exporters...), t.serverMap
+}
+
+// release marks the end of a request served by exporters returned by acquire.
+func release(exporters []*Exporter) {
+	for _, e := range exporters {
+		e.endScrape()
+	}
+}
+
+// Longest wait for the requests served by a removed exporter before closing it.
+const removedTargetCloseTimeout = time.Minute
+
+// SeedListResolver returns the list of target URIs and how long the result can be
+// cached. A zero TTL means the TTL is unknown.
+type SeedListResolver func(ctx context.Context) ([]string, time.Duration, error)
+
+// SeedListWatcherOpts holds the options of a SeedListWatcher.
+type SeedListWatcherOpts struct {
+	// Interval is the minimum time between two resolutions. A higher TTL
+	// reported by the resolver takes precedence.
+	Interval time.Duration
+	Resolve  SeedListResolver
+	// Build creates the exporter for a newly discovered target URI.
+	Build      func(uri string) *Exporter
+	Registerer prometheus.Registerer
+	Logger     *slog.Logger
+}
+
+// SeedListWatcher periodically resolves the list of target URIs (for example from
+// SRV records in split cluster mode) and keeps Targets in sync with it: exporters
+// are created for added hosts and closed for removed ones.
+type SeedListWatcher struct {
+	opts    SeedListWatcherOpts
+	targets *Targets
+	logger  *slog.Logger
+
+	// Exporters by the URI they were built from.
+	current map[string]*Exporter
+
+	resolutions   prometheus.Counter
+	errors        prometheus.Counter
+	lastSuccess   prometheus.Gauge
+	targetsGauge  prometheus.Gauge
+	targetChanges *prometheus.CounterVec
+}
+
+// NewSeedListWatcher creates a SeedListWatcher updating targets.
+func NewSeedListWatcher(opts SeedListWatcherOpts, targets *Targets) *SeedListWatcher {
+	w := &SeedListWatcher{
+		opts:    opts,
+		targets: targets,
+		logger:  opts.Logger.With("component", "seedlist_watcher"),
+		current: make(map[string]*Exporter),
+
+		resolutions: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "mongodb_exporter_seedlist_resolutions_total",
+			Help: "Total number of seed list resolutions",
+		}),
+		errors: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "mongodb_exporter_seedlist_resolution_errors_total",
+			Help: "Total number of failed seed list resolutions",
+		}),
+		lastSuccess: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "mongodb_exporter_seedlist_last_success_timestamp_seconds",
+			Help: "Unix timestamp of the last successful seed list resolution",
+		}),
+		targetsGauge: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "mongodb_exporter_seedlist_targets",
+			Help: "Number of targets in the resolved seed list",
+		}),
+		targetChanges: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "mongodb_exporter_seedlist_target_changes_total",
+			Help: "Total number of targets added to or removed from the seed list",
+		}, []string{"change"}),
+	}
+
+	if opts.Registerer != nil {
+		opts.Registerer.MustRegister(w.resolutions, w.errors, w.lastSuccess, w.targetsGauge, w.targetChanges)
+	}
+
+	return w
+}
+
+// Run resolves the seed list until the context is canceled.
+func (w *SeedListWatcher) Run(ctx context.Context) {
+	for {
+		next := w.Refresh(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(next):
+		}
+	}
+}
+
+// Refresh resolves the seed list once, updates the targets and returns the time
+// to wait before the next resolution. On errors the current targets are kept
+// unless there are none yet, in which case the partial result is used.
+func (w *SeedListWatcher) Refresh(ctx context.Context) time.Duration {
+	w.resolutions.Inc()
+
+	uris, ttl, err := w.opts.Resolve(ctx)
+	if err != nil {
+		w.errors.Inc()
+		w.logger.Error("Cannot resolve seed list", "error", err)
+
+		if len(w.current) == 0 {
+			w.update(ctx, uris)
+		}
+
+		return w.opts.Interval
+	}
+
+	w.lastSuccess.SetToCurrentTime()
+	w.update(ctx, uris)
+
+	return max(w.opts.Interval, ttl)
+}
+
+func (w *SeedListWatcher) update(ctx context.Context, uris []string) {
+	next := make(map[string]*Exporter, len(uris))
+	exporters := make([]*Exporter, 0, len(uris))
+
+	for _, uri := range uris {
+		if _, ok := next[uri]; ok {
+			continue
+		}
+
+		e, ok := w.current[uri]
+		if !ok {
+			w.logger.Info("Adding target", "uri", uri)
+			e = w.opts.Build(uri)
+			w.targetChanges.WithLabelValues("added").Inc()
+		}
+
+		next[uri] = e
+		exporters = append(exporters, e)
+	}
+
+	removed := make(map[string]*Exporter)
+	for uri, e := range w.current {
+		if _, ok := next[uri]; !ok {
+			removed[uri] = e
+		}
+	}
+
+	w.current = next
+	w.targets.Set(exporters)
+	w.targetsGauge.Set(float64(len(exporters)))
+
+	// Removed exporters are not served anymore, they are closed once the requests
+	// already being served are done.
+	for uri, e := range removed {
+		w.logger.Info("Removing target", "uri", uri)
+		w.targetChanges.WithLabelValues("removed").Inc()
+
+		go w.closeWhenIdle(ctx, uri, e)
+	}
+}
+
+// closeWhenIdle closes a removed exporter once it does not serve any request, or
+// after removedTargetCloseTimeout.
+func (w *SeedListWatcher) closeWhenIdle(ctx context.Context, uri string, e *Exporter) {
+	waitCtx, cancel := context.WithTimeout(ctx, removedTargetCloseTimeout)
+	defer cancel()
+
+	if err := e.waitIdle(waitCtx); err != nil {
+		w.logger.Warn("Closing exporter with requests in progress", "uri", uri, "error", err)
+	}
+
+	if err := e.Close(context.WithoutCancel(ctx)); err != nil {
+		w.logger.Warn("Cannot close exporter", "uri", uri, "error", err)
+	}
+}
@@ -0,0 +1,165 @@
+// mongodb_exporter
+// Copyright (C) 2017 Percona LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/promslog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSeedListWatcher(t *testing.T) {
+	t.Parallel()
+
+	logger := promslog.New(&promslog.Config{})
+	ctx := context.Background()
+
+	var resolved []string
+	var resolveErr error
+	built := map[string]int{}
+
+	reg := prometheus.NewRegistry()
+	targets := NewTargets(nil, logger)
+	w := NewSeedListWatcher(SeedListWatcherOpts{
+		Interval: time.Minute,
+		Resolve: func(context.Context) ([]string, time.Duration, error) {
+			return resolved, 5 * time.Minute, resolveErr
+		},
+		Build: func(uri string) *Exporter {
+			built[uri]++
+			return &Exporter{opts: &Opts{URI: uri}, logger: logger}
+		},
+		Registerer: reg,
+		Logger:     logger,
+	}, targets)
+
+	uris := func() []string {
+		var res []string
+		for _, e := range targets.Exporters() {
+			res = append(res, e.opts.URI)
+		}
+		return res
+	}
+
+	// Resolution fails before any target is known: the partial result is used.
+	resolved = []string{"mongodb://srv.example.com"}
+	resolveErr = errors.New("lookup failed")
+	assert.Equal(t, time.Minute, w.Refresh(ctx))
+	assert.Equal(t, []string{"mongodb://srv.example.com"}, uris())
+
+	// The TTL takes precedence over the shorter interval.
+	resolved = []string{"mongodb://h1:27017", "mongodb://h2:27017"}
+	resolveErr = nil
+	assert.Equal(t, 5*time.Minute, w.Refresh(ctx))
+	assert.Equal(t, []string{"mongodb://h1:27017", "mongodb://h2:27017"}, uris())
+	assert.Contains(t, targets.getServerMap(), "h1:27017")
+
+	// Hosts are replaced, existing exporters are reused.
+	resolved = []string{"mongodb://h2:27017", "mongodb://h3:27017"}
+	w.Refresh(ctx)
+	assert.Equal(t, []string{"mongodb://h2:27017", "mongodb://h3:27017"}, uris())
+	assert.Equal(t, 1, built["mongodb://h2:27017"])
+	assert.NotContains(t, targets.getServerMap(), "h1:27017")
+
+	// Failures keep the current targets.
+	resolved = nil
+	resolveErr = errors.New("lookup failed")
+	w.Refresh(ctx)
+	assert.Equal(t, []string{"mongodb://h2:27017", "mongodb://h3:27017"}, uris())
+
+	expected := strings.NewReader(`
+	# HELP mongodb_exporter_seedlist_resolution_errors_total Total number of failed seed list resolutions
+	# TYPE mongodb_exporter_seedlist_resolution_errors_total counter
+	mongodb_exporter_seedlist_resolution_errors_total 2
+	# HELP mongodb_exporter_seedlist_resolutions_total Total number of seed list resolutions
+	# TYPE mongodb_exporter_seedlist_resolutions_total counter
+	mongodb_exporter_seedlist_resolutions_total 4
+	# HELP mongodb_exporter_seedlist_target_changes_total Total number of targets added to or removed from the seed list
+	# TYPE mongodb_exporter_seedlist_target_changes_total counter
+	mongodb_exporter_seedlist_target_changes_total{change="added"} 4
+	mongodb_exporter_seedlist_target_changes_total{change="removed"} 2
+	# HELP mongodb_exporter_seedlist_targets Number of targets in the resolved seed list
+	# TYPE mongodb_exporter_seedlist_targets gauge
+	mongodb_exporter_seedlist_targets 2` + "\n")
+	err := testutil.GatherAndCompare(reg, expected,
+		"mongodb_exporter_seedlist_resolution_errors_total",
+		"mongodb_exporter_seedlist_resolutions_total",
+		"mongodb_exporter_seedlist_target_changes_total",
+		"mongodb_exporter_seedlist_targets")
+	assert.NoError(t, err)
+}
+
+func TestExporterWaitIdle(t *testing.T) {
+	t.Parallel()
+
+	e := &Exporter{}
+	assert.NoError(t, e.waitIdle(context.Background()))
+
+	e.beginScrape()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, e.waitIdle(ctx), context.DeadlineExceeded)
+
+	done := make(chan error)
+	go func() {
+		done <- e.waitIdle(context.Background())
+	}()
+
+	e.endScrape()
+	assert.NoError(t, <-done)
+}
+
+func TestTargetsAcquire(t *testing.T) {
+	t.Parallel()
+
+	logger := promslog.New(&promslog.Config{})
+	e := &Exporter{opts: &Opts{URI: "mongodb://h1:27017"}, logger: logger}
+	targets := NewTargets([]*Exporter{e}, logger)
+
+	exporters, serverMap := targets.acquire()
+	assert.Equal(t, []*Exporter{e}, exporters)
+	assert.Contains(t, serverMap, "h1:27017")
+
+	// The exporter removed while serving a request is not idle until it is released.
+	targets.Set(nil)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, e.waitIdle(ctx), context.DeadlineExceeded)
+
+	release(exporters)
+	assert.NoError(t, e.waitIdle(context.Background()))
+
+	exporters, _ = targets.acquire()
+	assert.Empty(t, exporters)
+}
+
+func TestExporterClosed(t *testing.T) {
+	t.Parallel()
+
+	e := &Exporter{opts: &Opts{URI: "mongodb://127.0.0.1:1", GlobalConnPool: true}}
+	assert.NoError(t, e.Close(context.Background()))
+
+	client, err := e.getClient(context.Background())
+	assert.Nil(t, client)
+	assert.ErrorIs(t, err, errExporterClosed)
+}
@@ -0,0 +1,214 @@
+// mongodb_exporter
+// Copyright (C) 2017 Percona LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exporter
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.mongodb.org/mongo-driver/event"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/address"
+	"go.mongodb.org/mongo-driver/mongo/description"
+
+	"github.com/percona/mongodb_exporter/exporter/dsn_fix"
+)
+
+// Names of the server states used by the topology changes counter.
+const (
+	topologyStatePrimary      = "primary"
+	topologyStateSecondary    = "secondary"
+	topologyStateArbiter      = "arbiter"
+	topologyStateOther        = "other"
+	topologyStateStandalone   = "standalone"
+	topologyStateMongos       = "mongos"
+	topologyStateLoadBalancer = "load_balancer"
+)
+
+// topologyCache keeps the topology info of a target between scrapes. The cached
+// value expires after the TTL and is dropped as soon as a state change of a server
+// is detected, either by the driver monitoring or when the topology is reloaded.
+type topologyCache struct {
+	ttl    time.Duration
+	logger *slog.Logger
+	// Address of the target as reported by the driver, empty if the URI has
+	// several hosts (or is an SRV URI) and the target is not a single server.
+	addr string
+
+	mu       sync.Mutex
+	ti       *topologyInfo
+	loadedAt time.Time
+	// Last known state by server address.
+	states map[string]string
+
+	changes *prometheus.CounterVec
+}
+
+func newTopologyCache(opts *Opts, logger *slog.Logger) *topologyCache {
+	return &topologyCache{
+		ttl:    opts.TopologyCacheTTL,
+		logger: logger.With("component", "topology_cache"),
+		addr:   targetAddress(opts.URI),
+		states: make(map[string]string),
+		changes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "mongodb_topology_changes_total",
+			Help: "Total number of server state changes (elections, role changes and replica set state changes)",
+		}, []string{"from", "to"}),
+	}
+}
+
+// get returns the cached topology info or loads it using client if it is missing,
+// expired or invalidated. Failed loads are not cached.
+func (c *topologyCache) get(ctx context.Context, client *mongo.Client) *topologyInfo {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.ti != nil && time.Since(c.loadedAt) < c.ttl {
+		return c.ti
+	}
+
+	ti := &topologyInfo{
+		client: client,
+		logger: c.logger,
+		labels: make(map[string]string),
+	}
+
+	if err := ti.loadLabels(ctx); err != nil {
+		c.logger.Warn("cannot load topology labels", "error", err)
+		c.ti = nil
+
+		return ti
+	}
+
+	if c.addr != "" {
+		c.observe(c.addr, topologyInfoState(ti))
+	}
+
+	c.ti = ti
+	c.loadedAt = time.Now()
+
+	return ti
+}
+
+// observe records the state of the server at addr and counts the change if the
+// state is not the previously known one. It must be called with mu held.
+func (c *topologyCache) observe(addr, state string) bool {
+	prev, ok := c.states[addr]
+	c.states[addr] = state
+
+	if !ok || prev == state {
+		return false
+	}
+
+	c.logger.Info("Topology change detected", "address", addr, "from", prev, "to", state)
+	c.changes.WithLabelValues(prev, state).Inc()
+
+	return true
+}
+
+// serverMonitor returns a driver monitor invalidating the cache when the driver
+// reports a state change. Transitions from or to the unknown state are ignored
+// since they happen each time a client connects or loses a server. The driver only
+// reports changes while the client is connected: with a client per scrape, changes
+// between scrapes are only seen when the cache expires.
+func (c *topologyCache) serverMonitor() *event.ServerMonitor {
+	return &event.ServerMonitor{
+		ServerDescriptionChanged: func(evt *event.ServerDescriptionChangedEvent) {
+			state := serverKindState(evt.NewDescription.Kind)
+			if state == "" {
+				return
+			}
+
+			c.mu.Lock()
+			defer c.mu.Unlock()
+
+			if c.observe(evt.Address.String(), state) {
+				c.ti = nil
+			}
+		},
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *topologyCache) Describe(ch chan<- *prometheus.Desc) {
+	c.changes.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (c *topologyCache) Collect(ch chan<- prometheus.Metric) {
+	c.changes.Collect(ch)
+}
+
+// targetAddress returns the canonical address of the only host of the URI, the same
+// way the driver reports it in its events, or an empty string.
+func targetAddress(uri string) string {
+	clientOpts, err := dsn_fix.ClientOptionsForDSN(uri)
+	if err != nil || len(clientOpts.Hosts) != 1 {
+		return ""
+	}
+
+	return address.Address(clientOpts.Hosts[0]).Canonicalize().String()
+}
+
+func serverKindState(kind description.ServerKind) string {
+	switch kind { //nolint:exhaustive
+	case description.RSPrimary:
+		return topologyStatePrimary
+	case description.RSSecondary:
+		return topologyStateSecondary
+	case description.RSArbiter:
+		return topologyStateArbiter
+	case description.RSMember, description.RSGhost:
+		return topologyStateOther
+	case description.Standalone:
+		return topologyStateStandalone
+	case description.Mongos:
+		return topologyStateMongos
+	case description.LoadBalancer:
+		return topologyStateLoadBalancer
+	default:
+		return ""
+	}
+}
+
+// topologyInfoState maps the topology info to the state names used for the driver
+// server kinds, so both sources agree on the state of a server.
+func topologyInfoState(ti labelsGetter) string {
+	labels := ti.baseLabels()
+
+	if state, ok := labels[labelReplicasetState]; ok {
+		switch n, _ := strconv.Atoi(state); n {
+		case PrimaryState:
+			return topologyStatePrimary
+		case SecondaryState:
+			return topologyStateSecondary
+		case ArbiterState:
+			return topologyStateArbiter
+		default:
+			return topologyStateOther
+		}
+	}
+
+	if ti.nodeType() == typeMongos {
+		return topologyStateMongos
+	}
+
+	return topologyStateStandalone
+}
@@ -47,6 +47,8 @@ const (
 
 type labelsGetter interface {
 	baseLabels() map[string]string
+	memberTags() map[string]string
+	nodeType() mongoDBNodeType
 	loadLabels(context.Context) error
 }
 
@@ -60,6 +62,9 @@ type topologyInfo struct {
 	logger *slog.Logger
 	rw     sync.RWMutex
 	labels map[string]string
+	// Tags of this member in the replica set configuration.
+	tags map[string]string
+	typ  mongoDBNodeType
 }
 
 // ErrCannotGetTopologyLabels Cannot read topology labels.
@@ -95,6 +100,27 @@ func (t *topologyInfo) baseLabels() map[string]string {
 	return c
 }
 
+// memberTags returns a copy of the replica set tags of the monitored member.
+func (t *topologyInfo) memberTags() map[string]string {
+	c := map[string]string{}
+
+	t.rw.RLock()
+	for k, v := range t.tags {
+		c[k] = v
+	}
+	t.rw.RUnlock()
+
+	return c
+}
+
+// nodeType returns the node type read with the labels, or typeOther if it is unknown.
+func (t *topologyInfo) nodeType() mongoDBNodeType {
+	t.rw.RLock()
+	defer t.rw.RUnlock()
+
+	return t.typ
+}
+
 // TopologyLabels reads several values from MongoDB instance like replicaset name, and other
 // topology information and returns a map of labels used to better identify the current monitored instance.
 func (t *topologyInfo) loadLabels(ctx context.Context) error {
@@ -102,6 +128,8 @@ func (t *topologyInfo) loadLabels(ctx context.Context) error {
 	defer t.rw.Unlock()
 
 	t.labels = make(map[string]string)
+	t.tags = make(map[string]string)
+	t.typ = typeOther
 
 	role, err := getClusterRole(ctx, t.client, t.logger)
 	if err != nil {
@@ -113,12 +141,14 @@ func (t *topologyInfo) loadLabels(ctx context.Context) error {
 	// Standalone instances or mongos instances won't have a replicaset name
 	if rs, err := util.ReplicasetConfig(ctx, t.client); err == nil {
 		t.labels[labelReplicasetName] = rs.Config.ID
+		t.tags = t.loadMemberTags(ctx, rs)
 	}
 
 	nodeType, err := getNodeType(ctx, t.client)
 	if err != nil {
 		return err
 	}
+	t.typ = nodeType
 
 	cid, err := util.ClusterID(ctx, t.client)
 	if err != nil {
@@ -137,6 +167,29 @@ func (t *topologyInfo) loadLabels(ctx context.Context) error {
 	return nil
 }
 
+// loadMemberTags returns the tags of the monitored member in the replica set config.
+func (t *topologyInfo) loadMemberTags(ctx context.Context, rs *proto.ReplicasetConfig) map[string]string {
+	tags := make(map[string]string)
+
+	role, err := util.MyRole(ctx, t.client)
+	if err != nil {
+		t.logger.Warn("cannot get replica set member name", "error", err)
+		return tags
+	}
+
+	for _, member := range rs.Config.Members {
+		if member.Host != role.Me {
+			continue
+		}
+
+		for k, v := range member.Tags {
+			tags[k] = fmt.Sprint(v)
+		}
+	}
+
+	return tags
+}
+
 func getNodeType(ctx context.Context, client *mongo.Client) (mongoDBNodeType, error) {
 	if client == nil {
 		return "", errors.New("cannot get mongo node type from an empty client")
@@ -157,6 +210,18 @@ func getNodeType(ctx context.Context, client *mongo.Client) (mongoDBNodeType, er
 	return typeMongod, nil
 }
 
+// nodeTypeOf returns the node type known by the topology info, falling back to
+// querying the server when it is not known.
+func nodeTypeOf(ctx context.Context, client *mongo.Client, topologyInfo labelsGetter) (mongoDBNodeType, error) {
+	if topologyInfo != nil {
+		if nodeType := topologyInfo.nodeType(); nodeType != typeOther {
+			return nodeType, nil
+		}
+	}
+
+	return getNodeType(ctx, client)
+}
+
 func getClusterRole(ctx context.Context, client *mongo.Client, logger *slog.Logger) (string, error) {
 	cmdOpts := primitive.M{}
 	// Not always we can get this info. For example, we cannot get this for hidden hosts so
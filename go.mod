@@ -19,7 +19,9 @@ require github.com/foxcpp/go-mockdns v1.2.0
 
 require (
 	github.com/hashicorp/go-version v1.9.0
+	github.com/miekg/dns v1.1.70
 	github.com/percona/percona-backup-mongodb v1.8.1-0.20251124214042-d06cab743541
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -94,7 +96,6 @@ require (
 	github.com/kylelemons/godebug v1.1.0 // indirect
 	github.com/mdlayher/socket v0.6.0 // indirect
 	github.com/mdlayher/vsock v1.3.0 // indirect
-	github.com/miekg/dns v1.1.70 // indirect
 	github.com/minio/crc64nvme v1.1.1 // indirect
 	github.com/minio/md5-simd v1.1.2 // indirect
 	github.com/minio/minio-go/v7 v7.0.97 // indirect
@@ -149,7 +150,6 @@ require (
 	google.golang.org/protobuf v1.36.11 // indirect
 	gopkg.in/ini.v1 v1.67.1 // indirect
 	gopkg.in/yaml.v2 v2.4.0 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 	mvdan.cc/gofumpt v0.9.2 // indirect
 )
 
@@ -0,0 +1,219 @@
+// mongodb_exporter
+// Copyright (C) 2017 Percona LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package mongodconf reads a local mongod.conf file and derives the connection
+// URI of the described instance, so an exporter running next to mongod does
+// not need a manually assembled URI.
+package mongodconf
+
+import (
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	defaultPort          = 27017
+	defaultShardsvrPort  = 27018
+	defaultConfigsvrPort = 27019
+	defaultSocketPrefix  = "/tmp"
+	defaultBindIP        = "127.0.0.1"
+
+	clusterRoleShardsvr  = "shardsvr"
+	clusterRoleConfigsvr = "configsvr"
+)
+
+// Config holds the subset of the mongod.conf settings the exporter needs to
+// connect to the local instance.
+type Config struct {
+	Net struct {
+		Port      int    `yaml:"port"`
+		BindIP    string `yaml:"bindIp"`
+		BindIPAll bool   `yaml:"bindIpAll"`
+		TLS       struct {
+			Mode   string `yaml:"mode"`
+			CAFile string `yaml:"CAFile"`
+		} `yaml:"tls"`
+		// ssl is the pre-4.2 name of the tls section.
+		SSL struct {
+			Mode   string `yaml:"mode"`
+			CAFile string `yaml:"CAFile"`
+		} `yaml:"ssl"`
+		UnixDomainSocket struct {
+			Enabled    *bool  `yaml:"enabled"`
+			PathPrefix string `yaml:"pathPrefix"`
+		} `yaml:"unixDomainSocket"`
+	} `yaml:"net"`
+	Replication struct {
+		ReplSetName string `yaml:"replSetName"`
+	} `yaml:"replication"`
+	Sharding struct {
+		ClusterRole string `yaml:"clusterRole"`
+	} `yaml:"sharding"`
+}
+
+// Load reads and parses the mongod.conf YAML file at path.
+func Load(path string) (*Config, error) {
+	buf, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot read mongod config file %q", path)
+	}
+
+	return Parse(buf)
+}
+
+// Parse parses the content of a mongod.conf YAML file.
+func Parse(buf []byte) (*Config, error) {
+	var cfg Config
+	if err := yaml.Unmarshal(buf, &cfg); err != nil {
+		return nil, errors.Wrap(err, "cannot parse mongod config file")
+	}
+
+	return &cfg, nil
+}
+
+// Port returns the configured port or the mongod default for the cluster role.
+func (c *Config) Port() int {
+	if c.Net.Port != 0 {
+		return c.Net.Port
+	}
+
+	switch c.Sharding.ClusterRole {
+	case clusterRoleShardsvr:
+		return defaultShardsvrPort
+	case clusterRoleConfigsvr:
+		return defaultConfigsvrPort
+	default:
+		return defaultPort
+	}
+}
+
+// ClusterRole returns the sharding cluster role (shardsvr, configsvr) or an
+// empty string for non-sharded instances.
+func (c *Config) ClusterRole() string {
+	return c.Sharding.ClusterRole
+}
+
+// ReplicaSetName returns the replica set name or an empty string.
+func (c *Config) ReplicaSetName() string {
+	return c.Replication.ReplSetName
+}
+
+// SocketPath returns the unix domain socket path mongod listens on, or an
+// empty string if unix domain sockets are disabled.
+func (c *Config) SocketPath() string {
+	if c.Net.UnixDomainSocket.Enabled != nil && !*c.Net.UnixDomainSocket.Enabled {
+		return ""
+	}
+
+	// A socket path listed in bindIp takes precedence over the default one.
+	for _, ip := range c.bindIPs() {
+		if strings.HasSuffix(ip, ".sock") {
+			return ip
+		}
+	}
+
+	prefix := c.Net.UnixDomainSocket.PathPrefix
+	if prefix == "" {
+		prefix = defaultSocketPrefix
+	}
+
+	return filepath.Join(prefix, "mongodb-"+strconv.Itoa(c.Port())+".sock")
+}
+
+// Host returns the host:port pair to reach mongod over TCP from the local machine.
+func (c *Config) Host() string {
+	host := defaultBindIP
+
+	if !c.Net.BindIPAll {
+		for _, ip := range c.bindIPs() {
+			if strings.HasSuffix(ip, ".sock") || ip == "0.0.0.0" || ip == "::" {
+				continue
+			}
+			host = ip
+
+			break
+		}
+	}
+
+	return net.JoinHostPort(host, strconv.Itoa(c.Port()))
+}
+
+// TLSEnabled returns true if clients must (or should) connect using TLS.
+func (c *Config) TLSEnabled() bool {
+	mode := c.Net.TLS.Mode
+	if mode == "" {
+		mode = c.Net.SSL.Mode
+	}
+
+	switch mode {
+	case "requireTLS", "preferTLS", "requireSSL", "preferSSL":
+		return true
+	default:
+		return false
+	}
+}
+
+// URI builds the connection URI for the local instance. The unix domain socket
+// is used when it exists on this machine, otherwise the TCP address is used. The
+// connection is direct: with the replica set name, the driver would discover the
+// members and could monitor another one.
+func (c *Config) URI() string {
+	host := c.Host()
+	useSocket := false
+
+	if socket := c.SocketPath(); socket != "" {
+		if _, err := os.Stat(socket); err == nil {
+			host = url.PathEscape(socket)
+			useSocket = true
+		}
+	}
+
+	params := url.Values{}
+	params.Set("directConnection", "true")
+
+	// Connections over the unix domain socket are not encrypted.
+	if !useSocket && c.TLSEnabled() {
+		params.Set("tls", "true")
+
+		caFile := c.Net.TLS.CAFile
+		if caFile == "" {
+			caFile = c.Net.SSL.CAFile
+		}
+		if caFile != "" {
+			params.Set("tlsCAFile", caFile)
+		}
+	}
+
+	return "mongodb://" + host + "/?" + params.Encode()
+}
+
+func (c *Config) bindIPs() []string {
+	var ips []string
+
+	for _, ip := range strings.Split(c.Net.BindIP, ",") {
+		if ip = strings.TrimSpace(ip); ip != "" {
+			ips = append(ips, ip)
+		}
+	}
+
+	return ips
+}
@@ -0,0 +1,196 @@
+// mongodb_exporter
+// Copyright (C) 2017 Percona LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mongodconf
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func TestURI(t *testing.T) {
+	t.Parallel()
+
+	socketDir := t.TempDir()
+	err := os.WriteFile(filepath.Join(socketDir, "mongodb-27017.sock"), nil, 0o600)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		config string
+		want   string
+	}{
+		{
+			name:   "empty config",
+			config: ``,
+			want:   "mongodb://127.0.0.1:27017/?directConnection=true",
+		},
+		{
+			name: "port and bind ip",
+			config: `
+net:
+  port: 27100
+  bindIp: 0.0.0.0,10.0.0.5
+`,
+			want: "mongodb://10.0.0.5:27100/?directConnection=true",
+		},
+		{
+			name: "bind ip all",
+			config: `
+net:
+  bindIpAll: true
+`,
+			want: "mongodb://127.0.0.1:27017/?directConnection=true",
+		},
+		{
+			name: "shard server default port and replica set",
+			config: `
+replication:
+  replSetName: rs1
+sharding:
+  clusterRole: shardsvr
+`,
+			want: "mongodb://127.0.0.1:27018/?directConnection=true",
+		},
+		{
+			name: "config server default port",
+			config: `
+sharding:
+  clusterRole: configsvr
+`,
+			want: "mongodb://127.0.0.1:27019/?directConnection=true",
+		},
+		{
+			name: "tls required",
+			config: `
+net:
+  tls:
+    mode: requireTLS
+    CAFile: /etc/ssl/ca.pem
+`,
+			want: "mongodb://127.0.0.1:27017/?directConnection=true&tls=true&tlsCAFile=%2Fetc%2Fssl%2Fca.pem",
+		},
+		{
+			name: "legacy ssl section",
+			config: `
+net:
+  ssl:
+    mode: preferSSL
+`,
+			want: "mongodb://127.0.0.1:27017/?directConnection=true&tls=true",
+		},
+		{
+			name: "existing unix socket and replica set",
+			config: `
+net:
+  unixDomainSocket:
+    pathPrefix: ` + socketDir + `
+  tls:
+    mode: requireTLS
+replication:
+  replSetName: rs0
+`,
+			want: "mongodb://" + url.PathEscape(socketDir) + "%2Fmongodb-27017.sock/?directConnection=true",
+		},
+		{
+			name: "disabled unix socket",
+			config: `
+net:
+  unixDomainSocket:
+    enabled: false
+    pathPrefix: ` + socketDir + `
+`,
+			want: "mongodb://127.0.0.1:27017/?directConnection=true",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg, err := Parse([]byte(tc.config))
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, cfg.URI())
+		})
+	}
+}
+
+func TestURIDirectConnection(t *testing.T) {
+	t.Parallel()
+
+	socketDir := t.TempDir()
+	err := os.WriteFile(filepath.Join(socketDir, "mongodb-27017.sock"), nil, 0o600)
+	require.NoError(t, err)
+
+	cfg, err := Parse([]byte(`
+net:
+  unixDomainSocket:
+    pathPrefix: ` + socketDir + `
+replication:
+  replSetName: rs0
+`))
+	require.NoError(t, err)
+
+	// The driver must monitor the local instance only, not discover the other
+	// members of the replica set.
+	opts := options.Client().ApplyURI(cfg.URI())
+	require.NoError(t, opts.Validate())
+	assert.Equal(t, []string{filepath.Join(socketDir, "mongodb-27017.sock")}, opts.Hosts)
+	require.NotNil(t, opts.Direct)
+	assert.True(t, *opts.Direct)
+	assert.Nil(t, opts.ReplicaSet)
+}
+
+func TestSocketPath(t *testing.T) {
+	t.Parallel()
+
+	cfg, err := Parse([]byte(`
+net:
+  port: 27200
+  bindIp: localhost,/var/run/mongodb/mongod.sock
+`))
+	require.NoError(t, err)
+
+	assert.Equal(t, "/var/run/mongodb/mongod.sock", cfg.SocketPath())
+	assert.Equal(t, "localhost:27200", cfg.Host())
+
+	cfg, err = Parse([]byte(`net: {port: 27200}`))
+	require.NoError(t, err)
+	assert.Equal(t, "/tmp/mongodb-27200.sock", cfg.SocketPath())
+}
+
+func TestLoad(t *testing.T) {
+	t.Parallel()
+
+	_, err := Load(filepath.Join(t.TempDir(), "missing.conf"))
+	assert.Error(t, err)
+
+	path := filepath.Join(t.TempDir(), "mongod.conf")
+	err = os.WriteFile(path, []byte("sharding:\n  clusterRole: shardsvr\n"), 0o600)
+	require.NoError(t, err)
+
+	cfg, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, "shardsvr", cfg.ClusterRole())
+
+	_, err = Parse([]byte("net: ["))
+	assert.Error(t, err)
+}
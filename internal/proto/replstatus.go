@@ -35,6 +35,7 @@ type StorageEngine struct {
 type Members struct {
 	Optime               map[string]Optime   `bson:"optimes"`              // See Optime struct
 	OptimeDate           primitive.DateTime  `bson:"optimeDate"`           // The last entry from the oplog that this member applied.
+	OptimeDurableDate    primitive.DateTime  `bson:"optimeDurableDate"`    // The last entry from the oplog that this member wrote to the journal.
 	InfoMessage          string              `bson:"infoMessage"`          // A message
 	ID                   int64               `bson:"_id"`                  // Server ID
 	Name                 string              `bson:"name"`                 // server name
@@ -50,31 +51,95 @@ type Members struct {
 	LastHeartbeatRecv    primitive.DateTime  `bson:"lastHeartbeatRecv"`    // Reflects the last time the server that processed the replSetGetStatus command received a heartbeat request from this member.
 	LastHeartbeatMessage string              `bson:"lastHeartbeatMessage"` // Contains a string representation of that message.
 	PingMs               *float64            `bson:"pingMs,omitempty"`     // Represents the number of milliseconds (ms) that a round-trip packet takes to travel between the remote member and the local instance.
+	SyncSourceHost       string              `bson:"syncSourceHost"`       // Member this member syncs from. 4.4+
+	SyncingTo            string              `bson:"syncingTo"`            // Member this member syncs from, before 4.4.
 	Set                  string              `bson:"-"`
 	StorageEngine        StorageEngine
 }
 
+// ReplicaSetOptimes holds the optimes of the replica set as seen by the member
+// returning replSetGetStatus.
+type ReplicaSetOptimes struct {
+	LastCommittedOpTime   Optime             `bson:"lastCommittedOpTime"`   // The most recent operation written to a majority of the members.
+	LastCommittedWallTime primitive.DateTime `bson:"lastCommittedWallTime"` // Wall clock time of lastCommittedOpTime. 4.2+
+	AppliedOpTime         Optime             `bson:"appliedOpTime"`         // The most recent operation applied to this member.
+	LastAppliedWallTime   primitive.DateTime `bson:"lastAppliedWallTime"`   // Wall clock time of appliedOpTime. 4.2+
+	DurableOpTime         Optime             `bson:"durableOpTime"`         // The most recent operation written to the journal of this member.
+	LastDurableWallTime   primitive.DateTime `bson:"lastDurableWallTime"`   // Wall clock time of durableOpTime. 4.2+
+}
+
+// InitialSyncCollection holds the initial sync progress of a collection.
+type InitialSyncCollection struct {
+	DocumentsToCopy   float64 `bson:"documentsToCopy"`
+	DocumentsCopied   float64 `bson:"documentsCopied"`
+	BytesToCopy       float64 `bson:"bytesToCopy"`       // 4.4+
+	ApproxBytesCopied float64 `bson:"approxBytesCopied"` // 4.4+
+}
+
+// InitialSyncStatus holds the progress of an initial sync.
+type InitialSyncStatus struct {
+	FailedInitialSyncAttempts           float64            `bson:"failedInitialSyncAttempts"`
+	MaxFailedInitialSyncAttempts        float64            `bson:"maxFailedInitialSyncAttempts"`
+	InitialSyncStart                    primitive.DateTime `bson:"initialSyncStart"`
+	TotalInitialSyncElapsedMillis       float64            `bson:"totalInitialSyncElapsedMillis"`
+	ApproxTotalDataSize                 float64            `bson:"approxTotalDataSize"`                 // 4.4+
+	ApproxTotalBytesCopied              float64            `bson:"approxTotalBytesCopied"`              // 4.4+
+	RemainingInitialSyncEstimatedMillis *float64           `bson:"remainingInitialSyncEstimatedMillis"` // 4.4+
+	// Databases holds the databasesToClone and databasesCloned counters and one
+	// document per database, itself holding one InitialSyncCollection per namespace.
+	Databases bson.Raw `bson:"databases"`
+}
+
+// ElectionCandidateMetrics holds the metrics of the last election won by a member.
+type ElectionCandidateMetrics struct {
+	LastElectionReason    string             `bson:"lastElectionReason"`
+	LastElectionDate      primitive.DateTime `bson:"lastElectionDate"`
+	ElectionTerm          float64            `bson:"electionTerm"`
+	NumVotesNeeded        float64            `bson:"numVotesNeeded"`
+	PriorityAtElection    float64            `bson:"priorityAtElection"`
+	ElectionTimeoutMillis float64            `bson:"electionTimeoutMillis"`
+	NumCatchUpOps         *float64           `bson:"numCatchUpOps"`    // Only set once the catch-up is over.
+	NewTermStartDate      primitive.DateTime `bson:"newTermStartDate"` // When the new primary started accepting writes, after the catch-up.
+}
+
+// ElectionParticipantMetrics holds the metrics of the last election a member voted in.
+type ElectionParticipantMetrics struct {
+	VotedForCandidate         bool               `bson:"votedForCandidate"`
+	ElectionTerm              float64            `bson:"electionTerm"`
+	LastVoteDate              primitive.DateTime `bson:"lastVoteDate"`
+	ElectionCandidateMemberID float64            `bson:"electionCandidateMemberId"`
+	PriorityAtElection        float64            `bson:"priorityAtElection"`
+}
+
 // Struct for replSetGetStatus
 type ReplicaSetStatus struct {
-	Date                    primitive.DateTime `bson:"date"`                    // Current date
-	MyState                 float64            `bson:"myState"`                 // Integer between 0 and 10 that represents the replica state of the current member
-	Term                    float64            `bson:"term"`                    // The election count for the replica set, as known to this replica set member. Mongo 3.2+
-	HeartbeatIntervalMillis float64            `bson:"heartbeatIntervalMillis"` // The frequency in milliseconds of the heartbeats. 3.2+
-	Members                 []Members          `bson:"members"`                 //
-	Ok                      float64            `bson:"ok"`                      //
-	Set                     string             `bson:"set"`                     // Replica set name
+	Date                       primitive.DateTime          `bson:"date"`                       // Current date
+	MyState                    float64                     `bson:"myState"`                    // Integer between 0 and 10 that represents the replica state of the current member
+	Term                       float64                     `bson:"term"`                       // The election count for the replica set, as known to this replica set member. Mongo 3.2+
+	HeartbeatIntervalMillis    float64                     `bson:"heartbeatIntervalMillis"`    // The frequency in milliseconds of the heartbeats. 3.2+
+	Members                    []Members                   `bson:"members"`                    //
+	Optimes                    ReplicaSetOptimes           `bson:"optimes"`                    // See ReplicaSetOptimes struct
+	MajorityVoteCount          int                         `bson:"majorityVoteCount"`          // Number of votes needed to elect a primary. 4.2.1+
+	WriteMajorityCount         int                         `bson:"writeMajorityCount"`         // Number of data bearing voting members needed for w:majority. 4.2.1+
+	SyncSourceHost             string                      `bson:"syncSourceHost"`             // Member this member syncs from. 4.4+
+	InitialSyncStatus          *InitialSyncStatus          `bson:"initialSyncStatus"`          // Only returned with initialSync: 1 during an initial sync.
+	ElectionCandidateMetrics   *ElectionCandidateMetrics   `bson:"electionCandidateMetrics"`   // Last election won by this member, while it is the primary. 4.2.1+
+	ElectionParticipantMetrics *ElectionParticipantMetrics `bson:"electionParticipantMetrics"` // Last election this member voted in. 4.2.1+
+	Ok                         float64                     `bson:"ok"`                         //
+	Set                        string                      `bson:"set"`                        // Replica set name
 }
 
 type Member struct {
-	Host         string  `bson:"host"`
-	Votes        int32   `bson:"votes"`
-	ID           int32   `bson:"_id"`
-	SlaveDelay   int64   `bson:"slaveDelay"`
-	Priority     float64 `bson:"priority"`
-	BuildIndexes bool    `bson:"buildIndexes"`
-	ArbiterOnly  bool    `bson:"arbiterOnly"`
-	Hidden       bool    `bson:"hidden"`
-	Tags         bson.M  `bson:"tags"`
+	Host               string  `bson:"host"`
+	Votes              int32   `bson:"votes"`
+	ID                 int32   `bson:"_id"`
+	SlaveDelay         int64   `bson:"slaveDelay"`
+	SecondaryDelaySecs int64   `bson:"secondaryDelaySecs"` // Replaces slaveDelay since MongoDB 5.0.
+	Priority           float64 `bson:"priority"`
+	BuildIndexes       bool    `bson:"buildIndexes"`
+	ArbiterOnly        bool    `bson:"arbiterOnly"`
+	Hidden             bool    `bson:"hidden"`
+	Tags               bson.M  `bson:"tags"`
 }
 
 type RSConfig struct {
@@ -82,6 +147,7 @@ type RSConfig struct {
 	ConfigServer                       bool       `bson:"configsvr"`
 	WriteConcernMajorityJournalDefault bool       `bson:"writeConcernMajorityJournalDefault"`
 	Version                            int32      `bson:"version"`
+	Term                               int64      `bson:"term"` // 4.4+
 	ProtocolVersion                    int64      `bson:"protocolVersion"`
 	Settings                           RSSettings `bson:"settings"`
 	Members                            []Member   `bson:"members"`
@@ -54,6 +54,16 @@ func MyRole(ctx context.Context, client *mongo.Client) (*proto.HelloResponse, er
 	return &role, nil
 }
 
+// ReplicasetStatus returns the replSetGetStatus result.
+func ReplicasetStatus(ctx context.Context, client *mongo.Client) (*proto.ReplicaSetStatus, error) {
+	var status proto.ReplicaSetStatus
+	if err := client.Database("admin").RunCommand(ctx, bson.M{"replSetGetStatus": 1}).Decode(&status); err != nil {
+		return nil, err
+	}
+
+	return &status, nil
+}
+
 func ReplicasetConfig(ctx context.Context, client *mongo.Client) (*proto.ReplicasetConfig, error) {
 	var rs proto.ReplicasetConfig
 	if err := client.Database("admin").RunCommand(ctx, bson.M{"replSetGetConfig": 1}).Decode(&rs); err != nil {
@@ -277,3 +277,58 @@ func TestBuildURI(t *testing.T) {
 		})
 	}
 }
+
+func TestResolveURIListErrors(t *testing.T) {
+	t.Parallel()
+
+	logger := promslog.New(&promslog.Config{})
+
+	// Unparsable URIs are skipped instead of exiting.
+	URIs, err := resolveURIList([]string{"mongodb://server1", "mongodb://%zz"}, logger, true)
+	assert.Error(t, err)
+	assert.Equal(t, []string{"mongodb://server1"}, URIs)
+
+	URIs, err = resolveURIList([]string{"mongodb://server1,server2"}, logger, true)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"mongodb://server1", "mongodb://server2"}, URIs)
+}
+
+func TestExternalLabels(t *testing.T) {
+	t.Parallel()
+
+	specs := []string{"env=prod", "team=db", "mongo1:27017/team=payments", "mongo2:27017/rack=r1"}
+
+	labels, err := parseExternalLabels(specs)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]map[string]string{
+		"":             {"env": "prod", "team": "db"},
+		"mongo1:27017": {"team": "payments"},
+		"mongo2:27017": {"rack": "r1"},
+	}, labels)
+
+	for nodeName, expected := range map[string]map[string]string{
+		"mongo1:27017": {"env": "prod", "team": "payments"},
+		"mongo2:27017": {"env": "prod", "team": "db", "rack": "r1"},
+		"":             {"env": "prod", "team": "db"},
+	} {
+		labels, err := targetLabels(specs, nodeName)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, labels, nodeName)
+	}
+
+	// A "/" in the value is not a target prefix.
+	labels, err = parseExternalLabels([]string{"team=db/ops", "mongo1:27017/path=/data/db", "url=http://x/y"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]map[string]string{
+		"":             {"team": "db/ops", "url": "http://x/y"},
+		"mongo1:27017": {"path": "/data/db"},
+	}, labels)
+
+	for _, invalid := range []string{"env", "1env=prod", "mongo1:27017/env", "en-v=prod", "a/b/c"} {
+		_, err := parseExternalLabels([]string{invalid})
+		assert.Error(t, err, invalid)
+	}
+
+	_, err = targetLabels([]string{"env"}, "")
+	assert.Error(t, err)
+}